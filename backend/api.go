@@ -2,31 +2,102 @@ package backend
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type API struct {
-	address   url.URL
-	embedder  Embedders
-	sqliteVec *SQLiteVec
+	address     url.URL
+	embedder    Embedders
+	searcher    *Searcher
+	sqliteVec   *SQLiteVec
+	authEnabled bool
+	dailyQuota  int64
+	queryLog    *QueryLogger
+	thresholds  DistanceThresholds
+	rewriter    *SwamaAPI
+}
+
+// APIOption configures optional behaviour of the [API].
+type APIOption func(*API)
+
+// WithAuthentication enables or disables API key authentication. It is
+// enabled by default.
+func WithAuthentication(enabled bool) APIOption {
+	return func(a *API) {
+		a.authEnabled = enabled
+	}
+}
+
+// WithDailyQuota limits the number of requests each API key can make per day
+// (UTC). A quota of zero disables the limit.
+func WithDailyQuota(requests int64) APIOption {
+	return func(a *API) {
+		a.dailyQuota = requests
+	}
+}
+
+// WithQueryLog logs searches for analytics with the logger. Searches aren't
+// logged by default.
+func WithQueryLog(logger *QueryLogger) APIOption {
+	return func(a *API) {
+		a.queryLog = logger
+	}
+}
+
+// WithDistanceThresholds sets the distance thresholds which results are
+// confident matches within. Models without a threshold use
+// [DefaultDistanceThreshold].
+func WithDistanceThresholds(thresholds DistanceThresholds) APIOption {
+	return func(a *API) {
+		a.thresholds = thresholds
+	}
+}
+
+// WithQueryRewriting rephrases queries without any confident matches using
+// the Swama API, and searches for the rephrased query instead if it matches
+// more closely. Queries aren't rewritten by default.
+func WithQueryRewriting(swama *SwamaAPI) APIOption {
+	return func(a *API) {
+		a.rewriter = swama
+	}
 }
 
 // NewAPI creates a new API instance with the provided [Embedder] backend and
 // SQLite vector database.
-func NewAPI(embedders Embedders, sqliteVec *SQLiteVec, address url.URL) *API {
-	return &API{
-		address:   address,
-		embedder:  embedders,
-		sqliteVec: sqliteVec,
+func NewAPI(
+	embedders Embedders,
+	sqliteVec *SQLiteVec,
+	address url.URL,
+	opts ...APIOption,
+) *API {
+	api := &API{
+		address:     address,
+		embedder:    embedders,
+		searcher:    NewSearcher(embedders, sqliteVec),
+		sqliteVec:   sqliteVec,
+		authEnabled: true,
 	}
+
+	for _, opt := range opts {
+		opt(api)
+	}
+
+	return api
 }
 
 // Serve returns an HTTP handler that serves the API.
@@ -36,10 +107,31 @@ func (a *API) Serve() http.Handler {
 	// Strip the API prefix.
 	router.Use(middleware.StripPrefix("/api"))
 
-	api := humachi.New(
-		router,
-		huma.DefaultConfig("Reverse Dictionary API", "0.0.1"),
-	)
+	a.register(router)
+
+	return router
+}
+
+// OpenAPI returns the OpenAPI description of the API, without serving it.
+func (a *API) OpenAPI() *huma.OpenAPI {
+	return a.register(chi.NewMux()).OpenAPI()
+}
+
+// register registers the API endpoints on the router.
+func (a *API) register(router chi.Router) huma.API {
+	config := huma.DefaultConfig("Reverse Dictionary API", "0.0.1")
+	config.Components.SecuritySchemes = map[string]*huma.SecurityScheme{
+		securitySchemeName: {
+			Type:         "http",
+			Scheme:       "bearer",
+			Description:  "API key issued with the `keys` command. May also be passed in the `X-API-Key` header.",
+			BearerFormat: APIKeyPrefix + "...",
+		},
+	}
+
+	api := humachi.New(router, config)
+
+	api.UseMiddleware(a.authenticate(api))
 
 	api.OpenAPI().Servers = []*huma.Server{
 		{
@@ -48,17 +140,107 @@ func (a *API) Serve() http.Handler {
 		},
 	}
 
-	// Register endpoints.
+	// Register endpoints. The operation IDs name the methods of the generated
+	// frontend client, so should not be changed lightly.
 	RegisterLogged(
 		api,
 		huma.Operation{
-			Method: http.MethodGet,
-			Path:   "/search",
+			OperationID: "search",
+			Summary:     "Search for words matching a description",
+			Method:      http.MethodGet,
+			Path:        "/search",
+			Security:    requireScope(ScopeSearch),
 		},
 		a.Search,
 	)
 
-	return router
+	RegisterLogged(
+		api,
+		huma.Operation{
+			OperationID: "suggest",
+			Summary:     "Suggest words for a partially typed query",
+			Method:      http.MethodGet,
+			Path:        "/suggest",
+			Security:    requireScope(ScopeSearch),
+		},
+		a.Suggest,
+	)
+
+	RegisterLogged(
+		api,
+		huma.Operation{
+			OperationID: "get-word",
+			Summary:     "Get a word and its features",
+			Method:      http.MethodGet,
+			Path:        "/words/{id}",
+			Security:    requireScope(ScopeSearch),
+		},
+		a.GetWord,
+	)
+
+	RegisterLogged(
+		api,
+		huma.Operation{
+			OperationID:   "add-feedback",
+			Summary:       "Give feedback on a search result",
+			Method:        http.MethodPost,
+			Path:          "/feedback",
+			Security:      requireScope(ScopeSearch),
+			DefaultStatus: http.StatusNoContent,
+		},
+		a.AddFeedback,
+	)
+
+	RegisterLogged(
+		api,
+		huma.Operation{
+			OperationID: "list-api-keys",
+			Summary:     "List the issued API keys",
+			Method:      http.MethodGet,
+			Path:        "/admin/keys",
+			Security:    requireScope(ScopeAdmin),
+		},
+		a.ListAPIKeys,
+	)
+
+	RegisterLogged(
+		api,
+		huma.Operation{
+			OperationID: "get-api-key-usage",
+			Summary:     "Get the daily usage of an API key",
+			Method:      http.MethodGet,
+			Path:        "/admin/keys/{id}/usage",
+			Security:    requireScope(ScopeAdmin),
+		},
+		a.GetAPIKeyUsage,
+	)
+
+	RegisterLogged(
+		api,
+		huma.Operation{
+			OperationID: "get-query-analytics",
+			Summary:     "Summarise the logged searches",
+			Method:      http.MethodGet,
+			Path:        "/admin/analytics",
+			Security:    requireScope(ScopeAdmin),
+		},
+		a.GetQueryAnalytics,
+	)
+
+	RegisterLogged(
+		api,
+		huma.Operation{
+			OperationID:   "revoke-api-key",
+			Summary:       "Revoke an API key",
+			Method:        http.MethodDelete,
+			Path:          "/admin/keys/{id}",
+			Security:      requireScope(ScopeAdmin),
+			DefaultStatus: http.StatusNoContent,
+		},
+		a.RevokeAPIKey,
+	)
+
+	return api
 }
 
 // Response structure for search results.
@@ -67,7 +249,28 @@ type SearchResponse struct {
 }
 
 type SearchResponseBody struct {
+	// Models lists the searched models in a stable order, as the results are
+	// keyed by model.
+	Models  []ModelDescription            `json:"models"`
 	Results map[Model][]SimilarDefinition `json:"results"`
+	// Fused are the results of all the models, re-ranked by the active
+	// [Ranker]. They are omitted if no ranker is active.
+	Fused []SimilarDefinition `json:"fused,omitempty"`
+	// Confidence is high if any of the results is a confident match.
+	Confidence Confidence `json:"confidence" enum:"high,low"`
+	// RewrittenQuery is set if the query had no confident matches, and the
+	// results are for this rephrasing of it instead ("did you mean").
+	RewrittenQuery string `json:"rewritten_query,omitempty"`
+	// Filtered is set if a safe search found nothing, but words which aren't
+	// rated safe (including unrated words) were excluded, so the content
+	// filter may have hidden the results.
+	Filtered bool `json:"filtered,omitempty"`
+}
+
+// ModelDescription describes an embedding model to users.
+type ModelDescription struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
 }
 
 func RegisterLogged[I, O any](
@@ -96,52 +299,339 @@ func RegisterLogged[I, O any](
 }
 
 // Search queries the DB for words with definitions that are semantically
-// similar to the provided query.
+// similar to the provided query. If there are no confident matches, the query
+// may be rewritten (see [WithQueryRewriting]). Searches only fail with 404 if
+// the DB is empty; otherwise, a lack of matches is signalled by the results'
+// confidence.
 func (a *API) Search(
 	ctx context.Context,
 	input *struct {
-		Query string `query:"query" json:"query" description:"The phrase to search for"`
-		Limit int    `query:"limit" json:"limit" description:"The maximum number of results to return" default:"10"`
+		Query string `query:"query" json:"query" required:"true" minLength:"1" description:"The phrase to search for"`
+		Limit int    `query:"limit" json:"limit" minimum:"1" maximum:"100" description:"The maximum number of results to return" default:"10"`
+		Safe  bool   `query:"safe" json:"safe" description:"Only return words rated safe for all audiences"`
 	},
-) (*SearchResponse, error) {
-	queryEmbeddings, err := a.embedder.Embed(ctx, input.Query)
+) (_ *SearchResponse, err error) {
+	ctx, span := tracer.Start(
+		ctx,
+		"API.Search",
+		trace.WithAttributes(
+			attribute.Int("query_length", len(input.Query)),
+			attribute.Int("limit", input.Limit),
+			attribute.Bool("safe", input.Safe),
+		),
+	)
+	defer func() { endSpan(span, err) }()
+
+	results, latencies, err := a.searcher.SearchTimed(ctx, input.Query, input.Limit, SearchModeAuto, input.Safe)
 	if err != nil {
-		return nil, fmt.Errorf(
-			"embedding query: %w",
-			err,
-		)
+		return nil, err
 	}
 
-	results := make(map[Model][]SimilarDefinition)
+	zeroResults := noResults(results)
 
-	for model, embeddings := range queryEmbeddings {
-		if len(embeddings) == 0 {
-			return nil, fmt.Errorf("no embeddings returned for query: %s", input.Query)
+	if zeroResults {
+		hasWords, err := a.sqliteVec.HasWords(ctx)
+		if err != nil {
+			return nil, err
 		}
 
-		modelResults, err := a.sqliteVec.RelatedWords(
-			ctx,
-			model,
-			embeddings[0],
-			input.Limit,
-		)
-		if err != nil {
-			return nil, fmt.Errorf(
-				"searching in SQLiteVec: %w",
-				err,
-			)
+		if !hasWords {
+			return nil, huma.Error404NotFound("the dictionary is empty")
 		}
+	}
+
+	if a.queryLog != nil {
+		a.queryLog.Log(ctx, LoggedSearch{
+			Query:       input.Query,
+			Safe:        input.Safe,
+			ZeroResults: zeroResults,
+			Results:     results,
+			Latencies:   latencies,
+		})
+	}
+
+	confidence := a.thresholds.Apply(results)
+
+	var rewrittenQuery string
+
+	if confidence == ConfidenceLow && a.rewriter != nil {
+		rewrittenQuery, results, confidence = a.rewriteSearch(ctx, input.Query, input.Limit, input.Safe, results)
+	}
+
+	var filtered bool
 
-		if len(modelResults) == 0 {
-			return nil, huma.Error404NotFound("no matching definitions found")
+	// Checked after rewriting, as the rewritten query may have found results.
+	if input.Safe && noResults(results) {
+		if filtered, err = a.sqliteVec.HasUnsafeWords(ctx); err != nil {
+			return nil, err
 		}
+	}
+
+	span.SetAttributes(
+		attribute.String("confidence", string(confidence)),
+		attribute.Bool("rewritten", rewrittenQuery != ""),
+	)
+
+	var fused []SimilarDefinition
 
-		results[model] = modelResults
+	ranker, err := a.sqliteVec.ActiveRanker(ctx)
+	if err == nil {
+		fused = ranker.Apply(results, input.Limit)
+	} else if !errors.Is(err, ErrNoActiveRanker) {
+		return nil, fmt.Errorf("getting active ranker: %w", err)
+	}
+
+	models := make([]ModelDescription, 0, len(results))
+
+	for _, model := range slices.Sorted(maps.Keys(results)) {
+		models = append(models, ModelDescription{
+			Name:        model.String(),
+			DisplayName: model.DisplayName(),
+		})
 	}
 
 	return &SearchResponse{
 		Body: SearchResponseBody{
-			Results: results,
+			Models:         models,
+			Results:        results,
+			Fused:          fused,
+			Confidence:     confidence,
+			RewrittenQuery: rewrittenQuery,
+			Filtered:       filtered,
 		},
 	}, nil
 }
+
+// rewriteSearch searches for a rewrite of a query without confident matches.
+// The rewrite's results are returned if they match more closely than the
+// original results; otherwise, the original results are returned, with no
+// rewritten query. Rewriting is best-effort, so failures are only logged.
+func (a *API) rewriteSearch(
+	ctx context.Context,
+	query string,
+	limit int,
+	safe bool,
+	results map[Model][]SimilarDefinition,
+) (string, map[Model][]SimilarDefinition, Confidence) {
+	rewrittenQuery, err := a.rewriter.RewriteQuery(ctx, query)
+	if err != nil {
+		slog.WarnContext(ctx, "rewriting query failed", slog.Any("error", err))
+
+		return "", results, ConfidenceLow
+	}
+
+	if strings.EqualFold(rewrittenQuery, strings.TrimSpace(query)) {
+		return "", results, ConfidenceLow
+	}
+
+	rewrittenResults, err := a.searcher.Search(ctx, rewrittenQuery, limit, SearchModeAuto, safe)
+	if err != nil {
+		slog.WarnContext(ctx, "searching for rewritten query failed", slog.Any("error", err))
+
+		return "", results, ConfidenceLow
+	}
+
+	if a.thresholds.closest(rewrittenResults) >= a.thresholds.closest(results) {
+		return "", results, ConfidenceLow
+	}
+
+	return rewrittenQuery, rewrittenResults, a.thresholds.Apply(rewrittenResults)
+}
+
+// Response structure for suggestions.
+type SuggestResponse struct {
+	Body *Suggestions
+}
+
+// Suggest returns suggestions for a partially typed query. Unlike [API.Search],
+// it never embeds the query, so is cheap enough to call as the user types.
+func (a *API) Suggest(
+	ctx context.Context,
+	input *struct {
+		Query string `query:"query" json:"query" required:"true" minLength:"1" description:"The partially typed query"`
+		Limit int    `query:"limit" json:"limit" minimum:"1" maximum:"20" description:"The maximum number of suggestions of each kind to return" default:"5"`
+		Safe  bool   `query:"safe" json:"safe" description:"Only suggest words rated safe for all audiences"`
+	},
+) (*SuggestResponse, error) {
+	suggestions, err := a.searcher.Suggest(ctx, input.Query, input.Limit, input.Safe)
+	if err != nil {
+		return nil, fmt.Errorf("suggesting words: %w", err)
+	}
+
+	a.thresholds.Apply(suggestions.Results)
+
+	return &SuggestResponse{
+		Body: suggestions,
+	}, nil
+}
+
+// Response structure for word details.
+type WordResponse struct {
+	Body WordResponseBody
+}
+
+type WordResponseBody struct {
+	ID       int64     `json:"id"`
+	Word     Word      `json:"definition"`
+	Features []Feature `json:"features"`
+}
+
+// GetWord returns a word, and the features it is searched by.
+func (a *API) GetWord(
+	ctx context.Context,
+	input *struct {
+		ID int64 `path:"id" description:"The ID of the word"`
+	},
+) (*WordResponse, error) {
+	word, err := a.sqliteVec.GetWord(ctx, input.ID)
+	if errors.Is(err, ErrWordNotFound) {
+		return nil, huma.Error404NotFound("word not found")
+	} else if err != nil {
+		return nil, fmt.Errorf("getting word: %w", err)
+	}
+
+	features, err := a.sqliteVec.GetWordPhrases(ctx, input.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getting word features: %w", err)
+	}
+
+	return &WordResponse{
+		Body: WordResponseBody{
+			ID:       word.ID,
+			Word:     word.Word,
+			Features: features,
+		},
+	}, nil
+}
+
+// AddFeedback records a user's verdict on a search result, so that result
+// quality can be measured.
+func (a *API) AddFeedback(
+	ctx context.Context,
+	input *struct {
+		Body struct {
+			Query   string  `json:"query" minLength:"1" description:"The query that was searched for"`
+			Model   string  `json:"model" description:"The model which returned the result"`
+			WordID  int64   `json:"word_id" description:"The ID of the result's word"`
+			Rank    int     `json:"rank" minimum:"1" description:"The 1-based position of the result in the model's results"`
+			Verdict Verdict `json:"verdict" enum:"relevant,irrelevant,meant" description:"Whether the result was relevant, irrelevant, or the word meant"`
+		}
+	},
+) (*struct{}, error) {
+	model, err := ModelFromString(input.Body.Model)
+	if err != nil {
+		return nil, huma.Error422UnprocessableEntity("unknown model", err)
+	}
+
+	if err := a.sqliteVec.AddFeedback(ctx, Feedback{
+		Query:   input.Body.Query,
+		Model:   model,
+		WordID:  input.Body.WordID,
+		Rank:    input.Body.Rank,
+		Verdict: input.Body.Verdict,
+	}); errors.Is(err, ErrWordNotFound) {
+		return nil, huma.Error404NotFound("word not found")
+	} else if err != nil {
+		return nil, fmt.Errorf("adding feedback: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Response structure for listing API keys.
+type ListAPIKeysResponse struct {
+	Body ListAPIKeysResponseBody
+}
+
+type ListAPIKeysResponseBody struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// ListAPIKeys lists all issued API keys.
+func (a *API) ListAPIKeys(
+	ctx context.Context,
+	_ *struct{},
+) (*ListAPIKeysResponse, error) {
+	keys, err := a.sqliteVec.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing api keys: %w", err)
+	}
+
+	return &ListAPIKeysResponse{
+		Body: ListAPIKeysResponseBody{
+			Keys: keys,
+		},
+	}, nil
+}
+
+// Response structure for API key usage.
+type APIKeyUsageResponse struct {
+	Body APIKeyUsageResponseBody
+}
+
+type APIKeyUsageResponseBody struct {
+	Usage []APIKeyUsage `json:"usage"`
+}
+
+// GetAPIKeyUsage returns the daily request counts of an API key.
+func (a *API) GetAPIKeyUsage(
+	ctx context.Context,
+	input *struct {
+		ID int64 `path:"id" description:"The ID of the API key"`
+	},
+) (*APIKeyUsageResponse, error) {
+	usage, err := a.sqliteVec.GetAPIKeyUsage(ctx, input.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getting api key usage: %w", err)
+	}
+
+	return &APIKeyUsageResponse{
+		Body: APIKeyUsageResponseBody{
+			Usage: usage,
+		},
+	}, nil
+}
+
+// RevokeAPIKey revokes an API key, so that it can no longer be used.
+func (a *API) RevokeAPIKey(
+	ctx context.Context,
+	input *struct {
+		ID int64 `path:"id" description:"The ID of the API key"`
+	},
+) (*struct{}, error) {
+	if err := a.sqliteVec.RevokeAPIKey(ctx, input.ID); errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, huma.Error404NotFound("api key not found")
+	} else if err != nil {
+		return nil, fmt.Errorf("revoking api key: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Response structure for query analytics.
+type QueryAnalyticsResponse struct {
+	Body *QueryAnalytics
+}
+
+// GetQueryAnalytics summarises the searches logged in the past days. Searches
+// are only logged if the query log is enabled.
+func (a *API) GetQueryAnalytics(
+	ctx context.Context,
+	input *struct {
+		Days  int `query:"days" minimum:"1" maximum:"365" default:"30" description:"The number of days to summarise"`
+		Limit int `query:"limit" minimum:"1" maximum:"100" default:"20" description:"The maximum number of queries to list"`
+	},
+) (*QueryAnalyticsResponse, error) {
+	analytics, err := a.sqliteVec.GetQueryAnalytics(
+		ctx,
+		time.Now().AddDate(0, 0, -input.Days),
+		input.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("getting query analytics: %w", err)
+	}
+
+	return &QueryAnalyticsResponse{
+		Body: analytics,
+	}, nil
+}
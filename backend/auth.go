@@ -0,0 +1,524 @@
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// APIKeyPrefix is prepended to every generated API key, so that keys are easy
+// to recognise (e.g. by secret scanners).
+const APIKeyPrefix = "rd_"
+
+// securitySchemeName is the name of the OpenAPI security scheme used for API
+// key authentication.
+const securitySchemeName = "apiKey"
+
+// ErrAPIKeyNotFound is returned when an API key does not exist, or has been
+// revoked.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// Scope grants an API key access to a set of endpoints.
+type Scope string
+
+const (
+	// ScopeSearch grants access to the search endpoints.
+	ScopeSearch Scope = "search"
+	// ScopeAdmin grants access to the administrative endpoints, and implies
+	// every other scope.
+	ScopeAdmin Scope = "admin"
+	// ScopeProxy marks the key of a trusted frontend, which makes requests on
+	// behalf of its users and identifies them with the [EndUserHeader]. Each
+	// user is rate limited separately, and the key is exempt from the daily
+	// quota, so that the frontend isn't throttled as a whole.
+	ScopeProxy Scope = "proxy"
+)
+
+// Scopes lists all the known scopes.
+var Scopes = []Scope{
+	ScopeSearch,
+	ScopeAdmin,
+	ScopeProxy,
+}
+
+// ScopeFromString parses a scope name.
+func ScopeFromString(s string) (Scope, error) {
+	scope := Scope(s)
+
+	if !slices.Contains(Scopes, scope) {
+		return "", fmt.Errorf("unknown scope: %s", s)
+	}
+
+	return scope, nil
+}
+
+// APIKey describes an issued API key. The key itself is never stored, only its
+// hash.
+type APIKey struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Scopes    []Scope    `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key has been granted the given scope.
+func (k *APIKey) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, scope) ||
+		slices.Contains(k.Scopes, ScopeAdmin)
+}
+
+// APIKeyUsage records how many requests were made with an API key on a given
+// day (UTC).
+type APIKeyUsage struct {
+	Day        string    `json:"day"`
+	Requests   int64     `json:"requests"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// generateAPIKey returns a new random API key.
+func generateAPIKey() (string, error) {
+	var secret [32]byte
+
+	if _, err := rand.Read(secret[:]); err != nil {
+		return "", fmt.Errorf("generating random key: %w", err)
+	}
+
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret[:]), nil
+}
+
+// hashAPIKey returns the hash of the API key that is stored in the DB.
+//
+// The keys are long and random, so a fast unsalted hash is sufficient.
+func hashAPIKey(key string) []byte {
+	hash := sha256.Sum256([]byte(key))
+
+	return hash[:]
+}
+
+func joinScopes(scopes []Scope) string {
+	names := make([]string, len(scopes))
+
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+
+	return strings.Join(names, ",")
+}
+
+func splitScopes(s string) ([]Scope, error) {
+	var scopes []Scope
+
+	for _, name := range strings.Split(s, ",") {
+		if name == "" {
+			continue
+		}
+
+		scope, err := ScopeFromString(name)
+		if err != nil {
+			return nil, err
+		}
+
+		scopes = append(scopes, scope)
+	}
+
+	return scopes, nil
+}
+
+// CreateAPIKey issues a new API key with the given scopes.
+//
+// The returned key string is only available at creation time.
+func (s *SQLiteVec) CreateAPIKey(
+	ctx context.Context,
+	name string,
+	scopes []Scope,
+) (string, *APIKey, error) {
+	if len(scopes) == 0 {
+		return "", nil, errors.New("at least one scope is required")
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	apiKey := APIKey{
+		Name:   name,
+		Scopes: scopes,
+	}
+
+	var createdAt int64
+
+	if err := s.db.QueryRowContext(
+		ctx,
+		`
+			INSERT INTO api_keys (name, key_hash, scopes)
+			VALUES (?, ?, ?)
+			RETURNING id, created_at
+		`,
+		name,
+		hashAPIKey(key),
+		joinScopes(scopes),
+	).Scan(&apiKey.ID, &createdAt); err != nil {
+		return "", nil, fmt.Errorf("inserting api key: %w", err)
+	}
+
+	apiKey.CreatedAt = time.Unix(createdAt, 0)
+
+	return key, &apiKey, nil
+}
+
+// RevokeAPIKey revokes the API key with the given ID.
+func (s *SQLiteVec) RevokeAPIKey(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`
+			UPDATE api_keys
+			SET revoked_at = unixepoch()
+			WHERE id = ? AND revoked_at IS NULL
+		`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("revoking api key: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking revoked api key: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// ListAPIKeys returns all API keys, including revoked ones.
+func (s *SQLiteVec) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+			SELECT id, name, scopes, created_at, revoked_at
+			FROM api_keys
+			ORDER BY id
+		`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying api keys: %w", err)
+	}
+
+	defer rows.Close()
+
+	var keys []APIKey
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// LookupAPIKey finds the active (non-revoked) API key matching the given key
+// string.
+func (s *SQLiteVec) LookupAPIKey(ctx context.Context, key string) (_ *APIKey, err error) {
+	ctx, span := startSQLiteSpan(ctx, "LookupAPIKey")
+	defer func() { endSpan(span, err) }()
+
+	row := s.db.QueryRowContext(
+		ctx,
+		`
+			SELECT id, name, scopes, created_at, revoked_at
+			FROM api_keys
+			WHERE key_hash = ? AND revoked_at IS NULL
+		`,
+		hashAPIKey(key),
+	)
+
+	apiKey, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+// RecordAPIKeyUsage counts a request made with the given API key, unless
+// `quota` requests have already been made with it that day (UTC). Returns false
+// if the quota is exhausted, in which case the request isn't counted. A quota
+// of zero is unlimited.
+func (s *SQLiteVec) RecordAPIKeyUsage(
+	ctx context.Context,
+	id int64,
+	at time.Time,
+	quota int64,
+) (_ bool, err error) {
+	ctx, span := startSQLiteSpan(
+		ctx,
+		"RecordAPIKeyUsage",
+		attribute.Int64("api_key_id", id),
+		attribute.Int64("quota", quota),
+	)
+	defer func() { endSpan(span, err) }()
+
+	var requests int64
+
+	// The quota is checked and the usage counted in one statement, so that
+	// concurrent requests can't both take the last request of the quota.
+	err = s.db.QueryRowContext(
+		ctx,
+		`
+			INSERT INTO api_key_usage (api_key_id, day, requests, last_used_at)
+			VALUES (?, ?, 1, ?)
+			ON CONFLICT(api_key_id, day) DO UPDATE SET
+				requests = requests + 1,
+				last_used_at = excluded.last_used_at
+			WHERE ? = 0 OR requests < ?
+			RETURNING requests
+		`,
+		id,
+		at.UTC().Format(time.DateOnly),
+		at.Unix(),
+		quota,
+		quota,
+	).Scan(&requests)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The conflicting row wasn't updated, as the quota is exhausted.
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("recording api key usage: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetAPIKeyUsage returns the daily usage of the given API key, most recent
+// first.
+func (s *SQLiteVec) GetAPIKeyUsage(ctx context.Context, id int64) ([]APIKeyUsage, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+			SELECT day, requests, last_used_at
+			FROM api_key_usage
+			WHERE api_key_id = ?
+			ORDER BY day DESC
+		`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying api key usage: %w", err)
+	}
+
+	defer rows.Close()
+
+	var usage []APIKeyUsage
+
+	for rows.Next() {
+		var (
+			day        APIKeyUsage
+			lastUsedAt int64
+		)
+
+		if err := rows.Scan(&day.Day, &day.Requests, &lastUsedAt); err != nil {
+			return nil, fmt.Errorf("scanning api key usage row: %w", err)
+		}
+
+		day.LastUsedAt = time.Unix(lastUsedAt, 0)
+
+		usage = append(usage, day)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating api key usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (*APIKey, error) {
+	var (
+		key       APIKey
+		scopes    string
+		createdAt int64
+		revokedAt sql.NullInt64
+	)
+
+	if err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&scopes,
+		&createdAt,
+		&revokedAt,
+	); err != nil {
+		return nil, fmt.Errorf("scanning api key row: %w", err)
+	}
+
+	parsedScopes, err := splitScopes(scopes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing scopes of api key %d: %w", key.ID, err)
+	}
+
+	key.Scopes = parsedScopes
+	key.CreatedAt = time.Unix(createdAt, 0)
+
+	if revokedAt.Valid {
+		revoked := time.Unix(revokedAt.Int64, 0)
+		key.RevokedAt = &revoked
+	}
+
+	return &key, nil
+}
+
+type apiKeyContextKey struct{}
+
+// APIKeyFromContext returns the API key that authenticated the current
+// request, if any.
+func APIKeyFromContext(ctx context.Context) (*APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(*APIKey)
+
+	return key, ok
+}
+
+// requireScope returns the security requirement for an operation that needs
+// the given scope.
+func requireScope(scope Scope) []map[string][]string {
+	return []map[string][]string{
+		{securitySchemeName: {string(scope)}},
+	}
+}
+
+// requestAPIKey extracts the API key from either the `Authorization: Bearer`
+// or the `X-API-Key` header.
+func requestAPIKey(header func(name string) string) string {
+	if bearer, ok := strings.CutPrefix(header("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(bearer)
+	}
+
+	return strings.TrimSpace(header("X-API-Key"))
+}
+
+// authenticate is a huma middleware that enforces the security requirements
+// of each operation.
+func (a *API) authenticate(api huma.API) func(ctx huma.Context, next func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		var required []Scope
+
+		for _, requirement := range ctx.Operation().Security {
+			for _, scope := range requirement[securitySchemeName] {
+				required = append(required, Scope(scope))
+			}
+		}
+
+		if len(required) == 0 || !a.authEnabled {
+			next(ctx)
+
+			return
+		}
+
+		key := requestAPIKey(ctx.Header)
+		if key == "" {
+			ctx.SetHeader("WWW-Authenticate", `Bearer realm="api"`)
+			huma.WriteErr(api, ctx, http.StatusUnauthorized, "missing API key")
+
+			return
+		}
+
+		var err error
+
+		// The rate limiter may have already validated the key.
+		apiKey, ok := APIKeyFromContext(ctx.Context())
+		if !ok {
+			apiKey, err = a.sqliteVec.LookupAPIKey(ctx.Context(), key)
+		}
+
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			ctx.SetHeader("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			huma.WriteErr(api, ctx, http.StatusUnauthorized, "invalid API key")
+
+			return
+		} else if err != nil {
+			slog.ErrorContext(
+				ctx.Context(),
+				"looking up api key",
+				slog.Any("error", err),
+			)
+			huma.WriteErr(api, ctx, http.StatusInternalServerError, "failed to authenticate")
+
+			return
+		}
+
+		for _, scope := range required {
+			if !apiKey.HasScope(scope) {
+				huma.WriteErr(
+					api,
+					ctx,
+					http.StatusForbidden,
+					fmt.Sprintf("API key lacks the %q scope", scope),
+				)
+
+				return
+			}
+		}
+
+		// Admin keys are exempt from the quota, so that they can always
+		// inspect and revoke other keys. Proxy keys are too, as they are shared
+		// by all the users of a frontend.
+		quota := a.dailyQuota
+		if apiKey.HasScope(ScopeAdmin) || apiKey.HasScope(ScopeProxy) {
+			quota = 0
+		}
+
+		// Only requests within the quota are counted, so that clients retrying
+		// after exceeding it don't keep adding to their usage.
+		withinQuota, err := a.sqliteVec.RecordAPIKeyUsage(ctx.Context(), apiKey.ID, time.Now(), quota)
+		if err != nil {
+			// Usage accounting is best-effort; a failure here shouldn't fail
+			// the request.
+			slog.WarnContext(
+				ctx.Context(),
+				"recording api key usage",
+				slog.Int64("api_key_id", apiKey.ID),
+				slog.Any("error", err),
+			)
+
+			withinQuota = true
+		}
+
+		if !withinQuota {
+			huma.WriteErr(
+				api,
+				ctx,
+				http.StatusTooManyRequests,
+				fmt.Sprintf("daily quota of %d requests exceeded", a.dailyQuota),
+			)
+
+			return
+		}
+
+		next(huma.WithValue(ctx, apiKeyContextKey{}, apiKey))
+	}
+}
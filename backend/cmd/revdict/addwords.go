@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/davidscholberg/go-urbandict"
+	"github.com/spf13/cobra"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+type addWordsArgs struct {
+	count     uint
+	rateLimit time.Duration
+}
+
+func addWordsCommand(app *app) *cobra.Command {
+	var args addWordsArgs
+
+	cmd := &cobra.Command{
+		Use:   "add-words",
+		Short: "Add random words from Urban Dictionary to the database",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return addWords(cmd.Context(), app, args)
+		},
+	}
+
+	cmd.Flags().UintVarP(
+		&args.count,
+		"count",
+		"c",
+		1,
+		"number of words to add",
+	)
+
+	cmd.Flags().DurationVarP(
+		&args.rateLimit,
+		"rate-limit",
+		"r",
+		1*time.Second,
+		"rate limit for adding words",
+	)
+
+	addModelFlag(cmd, "Models to embed the definitions with")
+
+	return cmd
+}
+
+// addedWord is the JSON output of `add-words`, one per word added.
+type addedWord struct {
+	ID int64 `json:"id"`
+	backend.Word
+}
+
+func addWords(ctx context.Context, app *app, args addWordsArgs) error {
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	embedders, err := app.cfg.Embedders(false)
+	if err != nil {
+		return fmt.Errorf("creating embedders: %w", err)
+	}
+
+	swama, err := app.cfg.SwamaAPI()
+	if err != nil {
+		return err
+	}
+
+	rateLimit := time.After(0)
+
+	for range args.count {
+		<-rateLimit
+
+		rateLimit = time.After(args.rateLimit)
+
+		randWord, err := urbandict.Random()
+		if err != nil {
+			return fmt.Errorf("getting random word: %w", err)
+		}
+
+		splitDef := backend.SplitDefinition(randWord.Definition)
+
+		modelEmbeddings, err := embedders.Embed(ctx, splitDef...)
+		if err != nil {
+			return fmt.Errorf("embedding word: %w", err)
+		}
+
+		features := make([]backend.Feature, len(splitDef))
+
+		for i, phrase := range splitDef {
+			features[i] = backend.Feature{
+				Phrase:        phrase,
+				Autogenerated: false,
+				Embeddings:    make(map[backend.Model]backend.Embedding, len(modelEmbeddings)),
+			}
+		}
+
+		for model, embeddings := range modelEmbeddings {
+			for i, embedding := range embeddings {
+				features[i].Embeddings[model] = embedding
+			}
+		}
+
+		definition := backend.Definition{
+			Word: backend.Word{
+				Author:     randWord.Author,
+				Word:       randWord.Word,
+				Definition: randWord.Definition,
+				Example:    randWord.Example,
+			},
+			Features: features,
+		}
+
+		// A failed classification leaves the word unrated, to be retried by
+		// `reingest`.
+		definition.ContentRating, err = swama.ClassifyContent(ctx, definition.Word)
+		if err != nil {
+			slog.WarnContext(
+				ctx,
+				"classifying content failed",
+				slog.String("word", randWord.Word),
+				slog.Any("error", err),
+			)
+
+			definition.ContentRating = backend.ContentRatingUnrated
+		}
+
+		id, err := db.AddDefinition(ctx, definition)
+		if err != nil {
+			return fmt.Errorf("adding word: %w", err)
+		}
+
+		slog.InfoContext(ctx, "added word", slog.String("word", randWord.Word))
+
+		if app.json {
+			if err := app.output(addedWord{ID: id, Word: definition.Word}, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
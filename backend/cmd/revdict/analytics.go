@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+type analyticsArgs struct {
+	days  int
+	limit int
+}
+
+func analyticsCommand(app *app) *cobra.Command {
+	var args analyticsArgs
+
+	cmd := &cobra.Command{
+		Use:   "analytics",
+		Short: "Summarise the searches logged by the API",
+		Long: `Summarise the searches logged by the API.
+
+Searches are only logged if the API is run with the query log enabled (the
+"query_log.mode" setting, or --query-log). The most frequent queries and
+queries with no results are listed, along with each model's latency, and how
+often each pair of models disagrees on the best word. Queries logged hashed
+are shown by their hash.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return analytics(cmd, app, args)
+		},
+	}
+
+	cmd.Flags().IntVar(&args.days, "days", 30, "Number of days to summarise")
+	cmd.Flags().IntVarP(&args.limit, "limit", "n", 20, "Maximum number of queries to list")
+
+	return cmd
+}
+
+func analytics(cmd *cobra.Command, app *app, args analyticsArgs) error {
+	ctx := cmd.Context()
+
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	analytics, err := db.GetQueryAnalytics(ctx, time.Now().AddDate(0, 0, -args.days), args.limit)
+	if err != nil {
+		return fmt.Errorf("getting query analytics: %w", err)
+	}
+
+	return app.output(analytics, func(out io.Writer) error {
+		fmt.Fprintf(
+			out,
+			"%d searches since %s, %d with no results.\n",
+			analytics.Searches,
+			analytics.Since.Format(time.DateOnly),
+			analytics.ZeroResults,
+		)
+
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+		fmt.Fprintln(w, "\nTOP QUERIES\tSEARCHES")
+		writeQueryCounts(w, analytics.TopQueries)
+
+		fmt.Fprintln(w, "\nZERO-RESULT QUERIES\tSEARCHES")
+		writeQueryCounts(w, analytics.ZeroResultQueries)
+
+		fmt.Fprintln(w, "\nMODEL\tSEARCHES\tZERO RESULTS\tMEAN LATENCY\tMAX LATENCY")
+
+		for _, m := range analytics.Models {
+			fmt.Fprintf(
+				w,
+				"%s\t%d\t%d\t%.0fms\t%.0fms\n",
+				m.Model,
+				m.Searches,
+				m.ZeroResults,
+				m.MeanLatencyMS,
+				m.MaxLatencyMS,
+			)
+		}
+
+		fmt.Fprintln(w, "\nMODEL\tOTHER MODEL\tSEARCHES\tTOP WORD DISAGREEMENT")
+
+		for _, d := range analytics.Disagreement {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%.1f%%\n", d.Model, d.OtherModel, d.Searches, d.Rate*100)
+		}
+
+		return w.Flush()
+	})
+}
+
+// writeQueryCounts writes a row for each query, showing hashed queries by
+// (the start of) their hash.
+func writeQueryCounts(w io.Writer, counts []backend.QueryCount) {
+	for _, count := range counts {
+		query := count.Query
+		if query == "" {
+			query = "#" + count.QueryHash[:12]
+		}
+
+		fmt.Fprintf(w, "%s\t%d\n", query, count.Searches)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/cors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	slogchi "github.com/samber/slog-chi"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+	"github.com/Crystalix007/reverse-dict/backend/config"
+)
+
+type apiArgs struct {
+	host            string
+	auth            bool
+	cacheSize       int
+	rewriteQueries  bool
+	traceExport     string
+	shutdownTimeout time.Duration
+}
+
+func apiCommand(app *app) *cobra.Command {
+	var args apiArgs
+
+	cmd := &cobra.Command{
+		Use:     "api",
+		Aliases: []string{"serve"},
+		Short:   "Start the API server",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runAPI(cmd.Context(), app, &args)
+		},
+	}
+
+	cmd.Flags().StringVarP(&args.host, "listen", "l", "localhost:8080", "Address to bind the server to")
+	cmd.Flags().BoolVar(&args.auth, "auth", true, "Require API keys for API requests")
+	cmd.Flags().Float64("rate-limit", 1, "Sustained requests per second allowed per client (0 to disable)")
+	cmd.Flags().Int("rate-burst", 10, "Maximum burst of requests allowed per client")
+	cmd.Flags().Int64("daily-quota", 0, "Maximum requests per API key per day, except admin and proxy keys (0 for unlimited)")
+	cmd.Flags().String("query-log", string(backend.QueryLogOff), "Log searches for analytics (off, hashed, raw)")
+	cmd.Flags().StringVar(&args.traceExport, "trace-exporter", string(backend.TraceExporterNone), "Where to send traces (none, otlp, stdout); otlp is configured with the OTEL_EXPORTER_OTLP_* environment variables")
+	cmd.Flags().BoolVar(&args.rewriteQueries, "rewrite-queries", true, "Rephrase queries without confident matches using the Swama API, and search again")
+	cmd.Flags().IntVar(&args.cacheSize, "embedding-cache-size", 1024, "Number of query embeddings to cache per model (0 to disable)")
+	cmd.Flags().DurationVar(&args.shutdownTimeout, "shutdown-timeout", backend.DefaultShutdownTimeout, "How long to wait for in-flight requests to complete when shutting down")
+
+	addModelFlag(cmd, "Models to use for query embeddings")
+	config.BindFlag(cmd.Flags(), "rate-limit", config.KeyRateLimitPerSecond)
+	config.BindFlag(cmd.Flags(), "rate-burst", config.KeyRateLimitBurst)
+	config.BindFlag(cmd.Flags(), "daily-quota", config.KeyRateLimitDailyQuota)
+	config.BindFlag(cmd.Flags(), "query-log", config.KeyQueryLogMode)
+
+	cmd.RegisterFlagCompletionFunc("query-log", cobra.FixedCompletions(
+		[]string{
+			string(backend.QueryLogOff),
+			string(backend.QueryLogHashed),
+			string(backend.QueryLogRaw),
+		},
+		cobra.ShellCompDirectiveNoFileComp,
+	))
+	cmd.RegisterFlagCompletionFunc("trace-exporter", cobra.FixedCompletions(
+		[]string{
+			string(backend.TraceExporterNone),
+			string(backend.TraceExporterOTLP),
+			string(backend.TraceExporterStdout),
+		},
+		cobra.ShellCompDirectiveNoFileComp,
+	))
+
+	return cmd
+}
+
+func runAPI(ctx context.Context, app *app, args *apiArgs) error {
+	cfg := app.cfg
+
+	traceExporter, err := backend.TraceExporterFromString(args.traceExport)
+	if err != nil {
+		return fmt.Errorf("parsing trace exporter: %w", err)
+	}
+
+	queryLog, err := backend.QueryLogModeFromString(cfg.QueryLog.Mode)
+	if err != nil {
+		return fmt.Errorf("parsing query log mode: %w", err)
+	}
+
+	shutdownTracing, err := backend.SetupTracing(ctx, "revdict-api", traceExporter)
+	if err != nil {
+		return fmt.Errorf("setting up tracing: %w", err)
+	}
+
+	defer shutdownTracing(context.Background())
+
+	embedders, err := cfg.Embedders(true)
+	if err != nil {
+		return fmt.Errorf("getting embedders: %w", err)
+	}
+
+	if args.cacheSize > 0 {
+		for model, embedder := range embedders {
+			embedders[model] = backend.NewCachingEmbedder(model, embedder, args.cacheSize)
+		}
+	}
+
+	thresholds, err := cfg.Thresholds()
+	if err != nil {
+		return err
+	}
+
+	apiOpts := []backend.APIOption{
+		backend.WithAuthentication(args.auth),
+		backend.WithDailyQuota(cfg.RateLimit.DailyQuota),
+		backend.WithDistanceThresholds(thresholds),
+	}
+
+	if args.rewriteQueries {
+		swama, err := cfg.SwamaAPI()
+		if err != nil {
+			return err
+		}
+
+		apiOpts = append(apiOpts, backend.WithQueryRewriting(swama))
+	}
+
+	// Opening a missing database would silently create an empty one, which
+	// can never return any results.
+	if _, err := os.Stat(cfg.DB.Path); err != nil {
+		return fmt.Errorf("checking database: %w", err)
+	}
+
+	sqlite, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	prometheus.MustRegister(backend.NewDBCollector(sqlite))
+
+	if queryLog != backend.QueryLogOff {
+		logger, err := backend.NewQueryLogger(sqlite, queryLog, []byte(cfg.QueryLog.HashKey))
+		if err != nil {
+			return fmt.Errorf("creating query log: %w (set %s)", err, config.KeyQueryLogHashKey)
+		}
+
+		// The server has drained by the time this runs, and the database is
+		// only closed once the command completes.
+		defer logger.Close()
+
+		apiOpts = append(apiOpts, backend.WithQueryLog(logger))
+	}
+
+	listenAddress := url.URL{
+		Scheme: "http",
+		Host:   args.host,
+	}
+
+	apiAddress := listenAddress
+	apiAddress.Path = "/api"
+
+	if !args.auth {
+		slog.WarnContext(ctx, "API key authentication is disabled")
+	}
+
+	api := backend.NewAPI(embedders, sqlite, apiAddress, apiOpts...)
+
+	// Create global mux.
+	router := chi.NewMux()
+
+	// Tracing wraps the whole router, so that the request logs can include
+	// the trace and span IDs.
+	server := backend.NewHTTPServer(args.host, otelhttp.NewHandler(router, "api"))
+
+	router.Use(
+		backend.MetricsMiddleware,
+		slogchi.NewWithConfig(
+			slog.Default(),
+			slogchi.Config{
+				DefaultLevel:  slog.LevelInfo,
+				WithTraceID:   true,
+				WithUserAgent: true,
+				WithSpanID:    true,
+			},
+		),
+	)
+
+	router.Handle("/metrics", promhttp.Handler())
+	router.Get("/healthz", api.Healthz)
+	router.Get("/readyz", api.Readyz)
+
+	// Add CORS to the API endpoints.
+	router.Group(func(router chi.Router) {
+		router.Use(cors.Handler(cors.Options{
+			AllowedOrigins:   []string{listenAddress.String()},
+			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"Content-Type", "Authorization", "X-API-Key"},
+			ExposedHeaders:   []string{"Content-Length", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
+			AllowCredentials: true,
+		}))
+
+		if cfg.RateLimit.PerSecond > 0 {
+			router.Use(
+				backend.NewRateLimiter(
+					cfg.RateLimit.PerSecond,
+					cfg.RateLimit.Burst,
+					sqlite.LookupAPIKey,
+				).Middleware,
+			)
+		}
+
+		router.Handle("/api/*", api.Serve())
+		router.Get("/api", http.RedirectHandler("/api/docs", http.StatusMovedPermanently).ServeHTTP)
+	})
+
+	slog.InfoContext(ctx, "Starting server", slog.String("address", listenAddress.String()))
+
+	// The database is closed once the server has drained, when the command
+	// completes.
+	return backend.ListenAndServe(ctx, server, args.shutdownTimeout)
+}
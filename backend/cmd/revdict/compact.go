@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+type compactArgs struct {
+	quantize          string
+	dropFullPrecision bool
+	prune             bool
+}
+
+func compactCommand(app *app) *cobra.Command {
+	var args compactArgs
+
+	cmd := &cobra.Command{
+		Use:   "compact",
+		Short: "Quantize embeddings and reclaim unused space in the database",
+		Long: `Quantize embeddings and reclaim unused space in the database.
+
+With --quantize, the embeddings of the selected models are quantized, and
+searches first scan the quantized embeddings before rescoring the best
+candidates at full precision. This speeds up searches, but the quantized
+embeddings are stored alongside the full-precision ones, so the database grows.
+
+With --drop-full-precision, the full-precision embeddings of the selected
+(quantized) models are deleted, shrinking the database. Searches then rank on
+the quantized distances alone, which is less accurate (especially for bit
+quantization), and the models can no longer be requantized or have variants
+derived from them without re-embedding the corpus.
+
+With --prune, the embeddings of all models which aren't selected are deleted.
+
+Running API servers must be restarted to pick up any changes.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return compact(cmd.Context(), app, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&args.quantize, "quantize", "", "Quantization to use for the selected models (none, int8, bit)")
+	cmd.Flags().BoolVar(&args.dropFullPrecision, "drop-full-precision", false, "Delete the full-precision embeddings of the selected models, keeping only the quantized ones")
+	cmd.Flags().BoolVar(&args.prune, "prune", false, "Delete the embeddings of models which aren't selected")
+	cmd.RegisterFlagCompletionFunc("quantize", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		names := make([]string, len(backend.Quantizations))
+
+		for i, quantization := range backend.Quantizations {
+			names[i] = string(quantization)
+		}
+
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	addModelFlag(cmd, "Models to quantize (and keep, with --prune)")
+
+	return cmd
+}
+
+// compactResult is the JSON output of `compact`.
+type compactResult struct {
+	Quantization map[backend.Model]backend.Quantization `json:"quantization"`
+	// FullPrecision is whether each model's full-precision embeddings are
+	// kept.
+	FullPrecision map[backend.Model]bool `json:"full_precision"`
+	// Dropped is the number of full-precision embeddings deleted per model.
+	Dropped    map[backend.Model]int64 `json:"dropped,omitempty"`
+	Pruned     map[backend.Model]int64 `json:"pruned,omitempty"`
+	SizeBefore int64                   `json:"size_before"`
+	SizeAfter  int64                   `json:"size_after"`
+}
+
+func compact(ctx context.Context, app *app, args compactArgs) error {
+	var quantization backend.Quantization
+
+	if args.quantize != "" {
+		var err error
+
+		quantization, err = backend.QuantizationFromString(args.quantize)
+		if err != nil {
+			return fmt.Errorf("parsing quantize flag: %w", err)
+		}
+	}
+
+	models, err := app.cfg.EnabledModels()
+	if err != nil {
+		return err
+	}
+
+	sizeBefore, err := databaseSize(app.cfg.DB.Path)
+	if err != nil {
+		return err
+	}
+
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := compactResult{
+		Quantization:  make(map[backend.Model]backend.Quantization),
+		FullPrecision: make(map[backend.Model]bool),
+		SizeBefore:    sizeBefore,
+	}
+
+	if args.dropFullPrecision {
+		result.Dropped = make(map[backend.Model]int64)
+	}
+
+	if args.prune {
+		result.Pruned = make(map[backend.Model]int64)
+
+		for _, model := range db.KnownModels() {
+			if slices.Contains(models, model) {
+				continue
+			}
+
+			deleted, err := db.DeleteModelEmbeddings(ctx, model)
+			if err != nil {
+				return fmt.Errorf("pruning embeddings of %s: %w", model, err)
+			}
+
+			result.Pruned[model] = deleted
+		}
+	}
+
+	for _, model := range models {
+		if quantization != "" {
+			slog.InfoContext(
+				ctx,
+				"quantizing embeddings",
+				slog.String("model", model.String()),
+				slog.String("quantization", string(quantization)),
+			)
+
+			if err := db.SetQuantization(ctx, model, quantization); err != nil {
+				return fmt.Errorf("quantizing embeddings of %s: %w", model, err)
+			}
+		}
+
+		result.Quantization[model], err = db.ModelQuantization(model)
+		if err != nil {
+			return err
+		}
+
+		if args.dropFullPrecision && db.KeepsFullPrecision(model) {
+			slog.InfoContext(ctx, "dropping full-precision embeddings", slog.String("model", model.String()))
+
+			result.Dropped[model], err = db.DropFullPrecision(ctx, model)
+			if err != nil {
+				return fmt.Errorf("dropping full-precision embeddings of %s: %w", model, err)
+			}
+		}
+
+		result.FullPrecision[model] = db.KeepsFullPrecision(model)
+	}
+
+	slog.InfoContext(ctx, "compacting database")
+
+	if err := db.Compact(ctx); err != nil {
+		return err
+	}
+
+	result.SizeAfter, err = databaseSize(app.cfg.DB.Path)
+	if err != nil {
+		return err
+	}
+
+	return app.output(result, func(w io.Writer) error {
+		var quantizedWithFullPrecision bool
+
+		for _, model := range models {
+			precision := "full precision kept"
+
+			if deleted, ok := result.Dropped[model]; ok {
+				precision = fmt.Sprintf("dropped %d full-precision embeddings", deleted)
+			} else if !result.FullPrecision[model] {
+				precision = "full precision dropped"
+			} else if result.Quantization[model] != backend.QuantizationNone {
+				quantizedWithFullPrecision = true
+			}
+
+			fmt.Fprintf(w, "%s: %s, %s\n", model, result.Quantization[model], precision)
+		}
+
+		for model, deleted := range result.Pruned {
+			fmt.Fprintf(w, "%s: pruned %d embeddings\n", model, deleted)
+		}
+
+		if _, err := fmt.Fprintf(
+			w,
+			"Database size: %.1f MiB -> %.1f MiB\n",
+			float64(result.SizeBefore)/(1<<20),
+			float64(result.SizeAfter)/(1<<20),
+		); err != nil {
+			return err
+		}
+
+		if quantizedWithFullPrecision {
+			_, err := fmt.Fprintln(
+				w,
+				"Quantized embeddings are stored in addition to the full-precision ones; "+
+					"use --drop-full-precision to remove those and shrink the database.",
+			)
+
+			return err
+		}
+
+		return nil
+	})
+}
+
+// databaseSize returns the size of the database, including its write-ahead
+// log.
+func databaseSize(path string) (int64, error) {
+	var size int64
+
+	for _, file := range []string{path, path + "-wal"} {
+		info, err := os.Stat(file)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return 0, fmt.Errorf("checking database size: %w", err)
+		}
+
+		size += info.Size()
+	}
+
+	return size, nil
+}
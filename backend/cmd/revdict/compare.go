@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+var (
+	ErrDocFlagRequired   = errors.New("at least one --doc or a --word is required")
+	ErrQueryFlagRequired = errors.New("at least one --query is required")
+	ErrWordNotFound      = errors.New("word not found in the database")
+)
+
+// docLabelLength is the maximum length of the document labels in the text
+// output.
+const docLabelLength = 60
+
+type compareArgs struct {
+	docs    []string
+	queries []string
+	word    string
+	metrics []string
+}
+
+func compareCommand(app *app) *cobra.Command {
+	var args compareArgs
+
+	cmd := &cobra.Command{
+		Use:   "compare",
+		Short: "Show the distances between document and query phrases for each model",
+		Long: `Show the distances between document and query phrases for each model.
+
+Documents are embedded as definitions, and queries as search queries, with
+every configured model. With --word, the stored features of the word in the
+database are also compared against the queries, using their stored
+embeddings.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return compare(cmd.Context(), app, args)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&args.docs, "doc", nil, "Document phrase to compare (may be repeated)")
+	cmd.Flags().StringArrayVar(&args.queries, "query", nil, "Query phrase to compare against (may be repeated)")
+	cmd.Flags().StringVar(&args.word, "word", "", "Word in the database whose features to compare")
+	cmd.Flags().StringSliceVar(
+		&args.metrics,
+		"metric",
+		[]string{string(backend.DistanceCosine)},
+		"Distance metrics to show (cosine, l2, dot); dot is a similarity, so higher is closer",
+	)
+	cmd.RegisterFlagCompletionFunc("metric", cobra.FixedCompletions(
+		[]string{
+			string(backend.DistanceCosine),
+			string(backend.DistanceL2),
+			string(backend.DistanceDot),
+		},
+		cobra.ShellCompDirectiveNoFileComp,
+	))
+
+	addModelFlag(cmd, "Models to compare with")
+
+	return cmd
+}
+
+// distanceMatrix is the JSON output of `compare`, one per model and metric.
+// Distances are indexed by document then query, and are null where a stored
+// feature has no embedding for the model.
+type distanceMatrix struct {
+	Model     backend.Model          `json:"model"`
+	Metric    backend.DistanceMetric `json:"metric"`
+	Docs      []string               `json:"docs"`
+	Queries   []string               `json:"queries"`
+	Distances [][]*float64           `json:"distances"`
+}
+
+// compareDoc is a document to compare, with its embeddings if already known.
+type compareDoc struct {
+	label      string
+	embeddings map[backend.Model]backend.Embedding
+}
+
+func compare(ctx context.Context, app *app, args compareArgs) error {
+	if len(args.queries) == 0 {
+		return ErrQueryFlagRequired
+	}
+
+	if len(args.docs) == 0 && args.word == "" {
+		return ErrDocFlagRequired
+	}
+
+	metrics := make([]backend.DistanceMetric, 0, len(args.metrics))
+
+	for _, name := range args.metrics {
+		metric, err := backend.DistanceMetricFromString(name)
+		if err != nil {
+			return fmt.Errorf("parsing metric flag: %w", err)
+		}
+
+		metrics = append(metrics, metric)
+	}
+
+	docEmbedders, err := app.cfg.Embedders(false)
+	if err != nil {
+		return fmt.Errorf("creating embedders: %w", err)
+	}
+
+	queryEmbedders, err := app.cfg.Embedders(true)
+	if err != nil {
+		return fmt.Errorf("creating embedders: %w", err)
+	}
+
+	var docs []compareDoc
+
+	if len(args.docs) > 0 {
+		docEmbeddings, err := docEmbedders.Embed(ctx, args.docs...)
+		if err != nil {
+			return fmt.Errorf("embedding docs: %w", err)
+		}
+
+		for i, doc := range args.docs {
+			embeddings := make(map[backend.Model]backend.Embedding, len(docEmbeddings))
+
+			for model, modelEmbeddings := range docEmbeddings {
+				embeddings[model] = modelEmbeddings[i]
+			}
+
+			docs = append(docs, compareDoc{
+				label:      doc,
+				embeddings: embeddings,
+			})
+		}
+	}
+
+	if args.word != "" {
+		wordDocs, err := wordFeatureDocs(ctx, app, args.word)
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, wordDocs...)
+	}
+
+	queryEmbeddings, err := queryEmbedders.Embed(ctx, args.queries...)
+	if err != nil {
+		return fmt.Errorf("embedding queries: %w", err)
+	}
+
+	models, err := app.cfg.EnabledModels()
+	if err != nil {
+		return err
+	}
+
+	var matrices []distanceMatrix
+
+	for _, model := range models {
+		for _, metric := range metrics {
+			matrix := distanceMatrix{
+				Model:     model,
+				Metric:    metric,
+				Queries:   args.queries,
+				Distances: make([][]*float64, len(docs)),
+			}
+
+			for i, doc := range docs {
+				matrix.Docs = append(matrix.Docs, doc.label)
+				matrix.Distances[i] = make([]*float64, len(args.queries))
+
+				docEmbedding, ok := doc.embeddings[model]
+				if !ok {
+					continue
+				}
+
+				for j, queryEmbedding := range queryEmbeddings[model] {
+					distance, err := metric.Distance(docEmbedding, queryEmbedding)
+					if err != nil {
+						return fmt.Errorf("comparing %q with %q: %w", doc.label, args.queries[j], err)
+					}
+
+					matrix.Distances[i][j] = &distance
+				}
+			}
+
+			matrices = append(matrices, matrix)
+		}
+	}
+
+	return app.output(matrices, func(w io.Writer) error {
+		return writeDistanceMatrices(w, args.queries, matrices)
+	})
+}
+
+// wordFeatureDocs returns the stored features of all the definitions of the
+// word as documents to compare.
+func wordFeatureDocs(ctx context.Context, app *app, word string) ([]compareDoc, error) {
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := db.FindWords(ctx, word)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(definitions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrWordNotFound, word)
+	}
+
+	var docs []compareDoc
+
+	for _, definition := range definitions {
+		features, err := db.GetWordFeatures(ctx, definition.ID)
+		if err != nil {
+			return nil, fmt.Errorf("getting word features: %w", err)
+		}
+
+		for _, feature := range features {
+			docs = append(docs, compareDoc{
+				label:      fmt.Sprintf("[%d] %s", definition.ID, feature.Phrase),
+				embeddings: feature.Embeddings,
+			})
+		}
+	}
+
+	return docs, nil
+}
+
+func writeDistanceMatrices(out io.Writer, queries []string, matrices []distanceMatrix) error {
+	fmt.Fprintln(out, "Queries:")
+
+	for i, query := range queries {
+		fmt.Fprintf(out, "  Q%d: %s\n", i+1, query)
+	}
+
+	for _, matrix := range matrices {
+		fmt.Fprintf(out, "\n%s (%s)\n", matrix.Model, matrix.Metric)
+
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+		fmt.Fprint(w, "DOC")
+
+		for i := range queries {
+			fmt.Fprintf(w, "\tQ%d", i+1)
+		}
+
+		fmt.Fprintln(w)
+
+		for i, doc := range matrix.Docs {
+			if runes := []rune(doc); len(runes) > docLabelLength {
+				doc = string(runes[:docLabelLength-3]) + "..."
+			}
+
+			fmt.Fprint(w, doc)
+
+			for _, distance := range matrix.Distances[i] {
+				if distance == nil {
+					fmt.Fprint(w, "\t-")
+				} else {
+					fmt.Fprintf(w, "\t%.4f", *distance)
+				}
+			}
+
+			fmt.Fprintln(w)
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+var ErrNoEmbeddingFound = errors.New("no embedding found for the provided phrase")
+
+func embedCommand(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "embed <phrase>",
+		Short: "Print the document embedding of a phrase as JSON",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return embed(cmd.Context(), app, args[0])
+		},
+	}
+}
+
+func embed(ctx context.Context, app *app, phrase string) error {
+	swama, err := app.cfg.SwamaAPI()
+	if err != nil {
+		return err
+	}
+
+	embeddings, err := swama.Embed(ctx, phrase)
+	if err != nil {
+		return fmt.Errorf("embedding phrase: %w", err)
+	}
+
+	if len(embeddings) == 0 {
+		return ErrNoEmbeddingFound
+	}
+
+	// The embedding is always printed as JSON, so `--json` only changes the
+	// formatting.
+	return app.output(embeddings[0], func(w io.Writer) error {
+		embeddingJSON, err := json.Marshal(embeddings[0])
+		if err != nil {
+			return fmt.Errorf("marshalling embedding: %w", err)
+		}
+
+		_, err = fmt.Fprintf(w, "%s\n", embeddingJSON)
+
+		return err
+	})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+type evalArgs struct {
+	k     int
+	modes []string
+}
+
+func evalCommand(app *app) *cobra.Command {
+	var args evalArgs
+
+	cmd := &cobra.Command{
+		Use:   "eval [queries.jsonl]",
+		Short: "Measure retrieval quality against a labelled set of queries",
+		Long: `Measure retrieval quality against a labelled set of queries.
+
+Each line of the queries file (or stdin, with "-") is a JSON object with the
+query, and the words it should find:
+
+  {"query": "a very small amount", "words": ["smidge", "tad"]}
+
+Without a queries file, the queries stored by "revdict generate-eval-queries"
+are used.
+
+Every query is searched for in the same way as the API, and recall@k, mean
+reciprocal rank and nDCG@k are reported for each model and search mode.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			var path string
+
+			if len(cmdArgs) > 0 {
+				path = cmdArgs[0]
+			}
+
+			return eval(cmd.Context(), app, path, args)
+		},
+	}
+
+	cmd.Flags().IntVarP(&args.k, "k", "k", 10, "Number of results to score for each query")
+	cmd.Flags().StringSliceVar(
+		&args.modes,
+		"mode",
+		[]string{string(backend.SearchModeAuto)},
+		"Search modes to evaluate (auto, exact); these only differ for quantized models",
+	)
+	cmd.RegisterFlagCompletionFunc("mode", cobra.FixedCompletions(
+		[]string{string(backend.SearchModeAuto), string(backend.SearchModeExact)},
+		cobra.ShellCompDirectiveNoFileComp,
+	))
+
+	addModelFlag(cmd, "Models to evaluate")
+
+	return cmd
+}
+
+func eval(ctx context.Context, app *app, path string, args evalArgs) error {
+	modes := make([]backend.SearchMode, 0, len(args.modes))
+
+	for _, name := range args.modes {
+		mode, err := backend.SearchModeFromString(name)
+		if err != nil {
+			return fmt.Errorf("parsing mode flag: %w", err)
+		}
+
+		modes = append(modes, mode)
+	}
+
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	var queries []backend.EvalQuery
+
+	if path != "" {
+		queries, err = readEvalQueries(path)
+	} else {
+		queries, err = db.GetEvalQueries(ctx)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(queries) == 0 {
+		return errors.New("no queries to evaluate")
+	}
+
+	embedders, err := app.cfg.Embedders(true)
+	if err != nil {
+		return fmt.Errorf("creating embedders: %w", err)
+	}
+
+	// Each query is searched for once per mode, but only needs embedding once.
+	for model, embedder := range embedders {
+		embedders[model] = backend.NewCachingEmbedder(model, embedder, len(queries))
+	}
+
+	metrics, err := backend.NewSearcher(embedders, db).Evaluate(ctx, queries, args.k, modes)
+	if err != nil {
+		return err
+	}
+
+	return app.output(metrics, func(out io.Writer) error {
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+		fmt.Fprintf(w, "MODEL\tMODE\tQUERIES\tRECALL@%d\tMRR\tNDCG@%d\n", args.k, args.k)
+
+		for _, m := range metrics {
+			fmt.Fprintf(
+				w,
+				"%s\t%s\t%d\t%.3f\t%.3f\t%.3f\n",
+				m.Model,
+				m.Mode,
+				m.Queries,
+				m.Recall,
+				m.MRR,
+				m.NDCG,
+			)
+		}
+
+		return w.Flush()
+	})
+}
+
+func readEvalQueries(path string) ([]backend.EvalQuery, error) {
+	if path == "-" {
+		return backend.ReadEvalQueries(os.Stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening queries: %w", err)
+	}
+
+	defer file.Close()
+
+	return backend.ReadEvalQueries(file)
+}
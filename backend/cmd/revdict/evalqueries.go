@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+)
+
+type generateEvalQueriesArgs struct {
+	count    uint
+	attempts uint
+}
+
+func generateEvalQueriesCommand(app *app) *cobra.Command {
+	var args generateEvalQueriesArgs
+
+	cmd := &cobra.Command{
+		Use:   "generate-eval-queries",
+		Short: "Generate labelled queries for random words, for use by eval",
+		Long: `Generate labelled queries for random words, for use by eval.
+
+For each word sampled from the database, the completion model writes a query
+that someone looking for the word might type, without using the word. The
+queries are stored in the database, and evaluated by "revdict eval" when no
+queries file is given.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return generateEvalQueries(cmd.Context(), app, args)
+		},
+	}
+
+	cmd.Flags().UintVarP(&args.count, "count", "c", 10, "Number of queries to generate")
+	cmd.Flags().UintVar(&args.attempts, "attempts", 3, "Number of attempts to generate a valid query for each word")
+
+	return cmd
+}
+
+// generatedQuery is the JSON output of `generate-eval-queries`, one per query
+// generated.
+type generatedQuery struct {
+	WordID int64  `json:"word_id"`
+	Word   string `json:"word"`
+	Query  string `json:"query"`
+}
+
+func generateEvalQueries(ctx context.Context, app *app, args generateEvalQueriesArgs) error {
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	swama, err := app.cfg.SwamaAPI()
+	if err != nil {
+		return err
+	}
+
+	for range args.count {
+		word, err := db.GetRandomDefinition(ctx)
+		if err != nil {
+			return fmt.Errorf("getting random definition: %w", err)
+		}
+
+		if word == nil {
+			return ErrNoWords
+		}
+
+		var query string
+
+		for range args.attempts {
+			query, err = swama.GenerateQuery(ctx, word.Word)
+			if err == nil {
+				break
+			}
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			slog.WarnContext(
+				ctx,
+				"generating query failed",
+				slog.String("word", word.Word.Word),
+				slog.Any("error", err),
+			)
+		}
+
+		// Give up on words which the model can't describe without using
+		// them.
+		if err != nil {
+			continue
+		}
+
+		added, err := db.AddEvalQuery(ctx, query, word.ID)
+		if err != nil {
+			return err
+		}
+
+		if !added {
+			continue
+		}
+
+		generated := generatedQuery{
+			WordID: word.ID,
+			Word:   word.Word.Word,
+			Query:  query,
+		}
+
+		if err := app.output(generated, func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "%s: %s\n", generated.Word, generated.Query)
+
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
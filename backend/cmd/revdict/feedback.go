@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func feedbackReportCommand(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "feedback-report",
+		Short: "Summarise the precision of each model from user feedback",
+		Long: `Summarise the precision of each model from user feedback.
+
+Users rate search results in the frontend as relevant, irrelevant, or the word
+they meant. For each model, the precision (the fraction of rated results which
+were relevant or meant) and the mean reciprocal rank of the words meant are
+reported.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return feedbackReport(cmd, app)
+		},
+	}
+}
+
+func feedbackReport(cmd *cobra.Command, app *app) error {
+	ctx := cmd.Context()
+
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	metrics, err := db.GetFeedbackMetrics(ctx)
+	if err != nil {
+		return fmt.Errorf("getting feedback metrics: %w", err)
+	}
+
+	if len(metrics) == 0 && !app.json {
+		return errors.New("no feedback has been given yet")
+	}
+
+	return app.output(metrics, func(out io.Writer) error {
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+		fmt.Fprintln(w, "MODEL\tVERDICTS\tRELEVANT\tIRRELEVANT\tMEANT\tPRECISION\tMEANT MRR")
+
+		for _, m := range metrics {
+			fmt.Fprintf(
+				w,
+				"%s\t%d\t%d\t%d\t%d\t%.3f\t%.3f\n",
+				m.Model,
+				m.Verdicts,
+				m.Relevant,
+				m.Irrelevant,
+				m.Meant,
+				m.Precision,
+				m.MeantMRR,
+			)
+		}
+
+		return w.Flush()
+	})
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+func keysCommand(app *app) *cobra.Command {
+	var (
+		name   string
+		scopes []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Manage API keys for the backend API",
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Issue a new API key",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return createKey(cmd, app, name, scopes)
+		},
+	}
+
+	createCmd.Flags().StringVar(&name, "name", "", "Human-readable name of the key's owner")
+	createCmd.Flags().StringSliceVar(
+		&scopes,
+		"scope",
+		[]string{string(backend.ScopeSearch)},
+		"Scopes to grant the key (search, admin, proxy); give a frontend's key search and proxy",
+	)
+	createCmd.MarkFlagRequired("name")
+	createCmd.RegisterFlagCompletionFunc("scope", cobra.FixedCompletions(
+		[]string{string(backend.ScopeSearch), string(backend.ScopeAdmin), string(backend.ScopeProxy)},
+		cobra.ShellCompDirectiveNoFileComp,
+	))
+
+	revokeCmd := &cobra.Command{
+		Use:   "revoke <id>",
+		Short: "Revoke an API key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return revokeKey(cmd, app, cmdArgs[0])
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all API keys",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return listKeys(cmd, app)
+		},
+	}
+
+	usageCmd := &cobra.Command{
+		Use:   "usage <id>",
+		Short: "Show the daily usage of an API key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return keyUsage(cmd, app, cmdArgs[0])
+		},
+	}
+
+	cmd.AddCommand(createCmd, revokeCmd, listCmd, usageCmd)
+
+	return cmd
+}
+
+// createdKey is the JSON output of `keys create`.
+type createdKey struct {
+	Key string `json:"key"`
+	*backend.APIKey
+}
+
+func createKey(cmd *cobra.Command, app *app, name string, scopeNames []string) error {
+	scopes := make([]backend.Scope, 0, len(scopeNames))
+
+	for _, name := range scopeNames {
+		scope, err := backend.ScopeFromString(name)
+		if err != nil {
+			return fmt.Errorf("parsing scope flag: %w", err)
+		}
+
+		scopes = append(scopes, scope)
+	}
+
+	ctx := cmd.Context()
+
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, apiKey, err := db.CreateAPIKey(ctx, name, scopes)
+	if err != nil {
+		return fmt.Errorf("creating api key: %w", err)
+	}
+
+	return app.output(createdKey{Key: key, APIKey: apiKey}, func(w io.Writer) error {
+		fmt.Fprintf(os.Stderr, "Created API key %d for %q. It will not be shown again.\n", apiKey.ID, apiKey.Name)
+		_, err := fmt.Fprintln(w, key)
+
+		return err
+	})
+}
+
+func revokeKey(cmd *cobra.Command, app *app, idArg string) error {
+	id, err := strconv.ParseInt(idArg, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parsing key id: %w", err)
+	}
+
+	ctx := cmd.Context()
+
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := db.RevokeAPIKey(ctx, id); err != nil {
+		return fmt.Errorf("revoking api key %d: %w", id, err)
+	}
+
+	return app.output(map[string]int64{"revoked": id}, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "Revoked API key %d\n", id)
+
+		return err
+	})
+}
+
+func listKeys(cmd *cobra.Command, app *app) error {
+	ctx := cmd.Context()
+
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := db.ListAPIKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("listing api keys: %w", err)
+	}
+
+	return app.output(keys, func(out io.Writer) error {
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+		fmt.Fprintln(w, "ID\tNAME\tSCOPES\tCREATED\tREVOKED")
+
+		for _, key := range keys {
+			scopes := make([]string, len(key.Scopes))
+
+			for i, scope := range key.Scopes {
+				scopes[i] = string(scope)
+			}
+
+			revoked := "-"
+			if key.RevokedAt != nil {
+				revoked = key.RevokedAt.Format(time.RFC3339)
+			}
+
+			fmt.Fprintf(
+				w,
+				"%d\t%s\t%s\t%s\t%s\n",
+				key.ID,
+				key.Name,
+				strings.Join(scopes, ","),
+				key.CreatedAt.Format(time.RFC3339),
+				revoked,
+			)
+		}
+
+		return w.Flush()
+	})
+}
+
+func keyUsage(cmd *cobra.Command, app *app, idArg string) error {
+	id, err := strconv.ParseInt(idArg, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parsing key id: %w", err)
+	}
+
+	ctx := cmd.Context()
+
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	days, err := db.GetAPIKeyUsage(ctx, id)
+	if err != nil {
+		return fmt.Errorf("getting api key usage: %w", err)
+	}
+
+	return app.output(days, func(out io.Writer) error {
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+		fmt.Fprintln(w, "DAY\tREQUESTS\tLAST USED")
+
+		for _, day := range days {
+			fmt.Fprintf(
+				w,
+				"%s\t%d\t%s\n",
+				day.Day,
+				day.Requests,
+				day.LastUsedAt.Format(time.RFC3339),
+			)
+		}
+
+		return w.Flush()
+	})
+}
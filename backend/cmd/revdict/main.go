@@ -0,0 +1,160 @@
+// Command revdict is the single entrypoint for the reverse dictionary backend:
+// the API server, and the tools to build and inspect the word database.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+	"github.com/Crystalix007/reverse-dict/backend/config"
+)
+
+// app holds the state shared by all subcommands.
+type app struct {
+	cfg  *config.Config
+	json bool
+	db   *backend.SQLiteVec
+}
+
+func main() {
+	app := &app{}
+
+	rootCmd := &cobra.Command{
+		Use:   "revdict",
+		Short: "Reverse dictionary: find words from their definitions",
+		// Errors are logged below instead.
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			// Flags and arguments have been validated by now, so any further
+			// errors aren't usage errors.
+			cmd.SilenceUsage = true
+
+			cfg, err := config.Setup(cmd)
+			if err != nil {
+				return err
+			}
+
+			app.cfg = cfg
+
+			return nil
+		},
+		PersistentPostRunE: func(*cobra.Command, []string) error {
+			return app.close()
+		},
+	}
+
+	config.AddFlags(rootCmd)
+
+	rootCmd.PersistentFlags().BoolVar(&app.json, "json", false, "Output machine-readable JSON")
+	rootCmd.RegisterFlagCompletionFunc("log-level", cobra.FixedCompletions(
+		[]string{"debug", "info", "warn", "error"},
+		cobra.ShellCompDirectiveNoFileComp,
+	))
+
+	rootCmd.AddCommand(
+		apiCommand(app),
+		addWordsCommand(app),
+		analyticsCommand(app),
+		compactCommand(app),
+		compareCommand(app),
+		embedCommand(app),
+		evalCommand(app),
+		feedbackReportCommand(app),
+		generateEvalQueriesCommand(app),
+		keysCommand(app),
+		openAPICommand(app),
+		randomWordCommand(app),
+		reingestCommand(app),
+		rephraseRandomWordCommand(app),
+		searchPhraseCommand(app),
+		trainRankerCommand(app),
+	)
+
+	// Cancel the context on SIGINT/SIGTERM, so that the API server can drain,
+	// and long-running commands can stop cleanly.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		app.close()
+
+		slog.Error("revdict failed", slog.Any("error", err))
+		stop()
+		os.Exit(1)
+	}
+}
+
+// openDB opens the configured database, reusing it if it is already open. It
+// is closed once the command completes.
+func (a *app) openDB(ctx context.Context) (*backend.SQLiteVec, error) {
+	if a.db != nil {
+		return a.db, nil
+	}
+
+	db, err := a.cfg.OpenDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.db = db
+
+	return db, nil
+}
+
+func (a *app) close() error {
+	if a.db == nil {
+		return nil
+	}
+
+	err := a.db.Close()
+	a.db = nil
+
+	return err
+}
+
+// output writes the value as JSON if `--json` was given, otherwise calls
+// `text` to write it in a human-readable form.
+func (a *app) output(v any, text func(w io.Writer) error) error {
+	if a.json {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(v); err != nil {
+			return fmt.Errorf("encoding JSON output: %w", err)
+		}
+
+		return nil
+	}
+
+	return text(os.Stdout)
+}
+
+// addModelFlag adds a `--model` flag bound to the enabled models in the
+// config.
+func addModelFlag(cmd *cobra.Command, usage string) {
+	cmd.Flags().StringSlice("model", nil, usage)
+	config.BindFlag(cmd.Flags(), "model", config.KeyModels)
+	cmd.RegisterFlagCompletionFunc("model", completeModels)
+}
+
+// completeModels completes the names of the supported models. Truncated
+// variants are selected by adding an `@<dimensions>` suffix to their base
+// model.
+func completeModels(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+
+	for _, model := range backend.Models {
+		names = append(names, model.String())
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
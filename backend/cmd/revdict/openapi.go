@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+type openAPIArgs struct {
+	output  string
+	version string
+}
+
+func openAPICommand(app *app) *cobra.Command {
+	var args openAPIArgs
+
+	cmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Print the OpenAPI description of the API",
+		Long: `Print the OpenAPI description of the API, as YAML (or JSON with --json).
+
+The API serves OpenAPI 3.1, but code generators such as oapi-codegen only
+support 3.0, so the description may be downgraded with --openapi-version.`,
+		Args: cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			return writeOpenAPI(app, &args)
+		},
+	}
+
+	cmd.Flags().StringVarP(&args.output, "output", "o", "-", "File to write the description to (- for stdout)")
+	cmd.Flags().StringVar(&args.version, "openapi-version", "3.1", "OpenAPI version to output (3.1, 3.0)")
+
+	cmd.RegisterFlagCompletionFunc("openapi-version", cobra.FixedCompletions(
+		[]string{"3.1", "3.0"},
+		cobra.ShellCompDirectiveNoFileComp,
+	))
+
+	return cmd
+}
+
+func writeOpenAPI(app *app, args *openAPIArgs) error {
+	// The description doesn't depend on the embedders or database, which are
+	// only used when serving requests.
+	spec := backend.NewAPI(nil, nil, url.URL{Path: "/api"}).OpenAPI()
+
+	var (
+		data []byte
+		err  error
+	)
+
+	switch {
+	case args.version == "3.1" && app.json:
+		data, err = spec.MarshalJSON()
+	case args.version == "3.1":
+		data, err = spec.YAML()
+	case args.version == "3.0" && app.json:
+		data, err = spec.Downgrade()
+	case args.version == "3.0":
+		data, err = spec.DowngradeYAML()
+	default:
+		return fmt.Errorf("unsupported OpenAPI version: %s", args.version)
+	}
+
+	if err != nil {
+		return fmt.Errorf("encoding OpenAPI description: %w", err)
+	}
+
+	if args.output == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(args.output, data, 0o644)
+	}
+
+	if err != nil {
+		return fmt.Errorf("writing OpenAPI description: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/davidscholberg/go-urbandict"
+	"github.com/spf13/cobra"
+)
+
+func randomWordCommand(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "random-word",
+		Short: "Print a random word from Urban Dictionary",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			definition, err := urbandict.Random()
+			if err != nil {
+				return fmt.Errorf("getting random word: %w", err)
+			}
+
+			return app.output(definition, func(w io.Writer) error {
+				_, err := fmt.Fprintf(w, "[%s] %s: %s\n", definition.Author, definition.Word, definition.Definition)
+
+				return err
+			})
+		},
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+func reingestCommand(app *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reingest",
+		Short: "Generate missing content ratings, features and embeddings for all words",
+		Long: `Generate missing content ratings, features and embeddings for all words.
+
+Words are rated by the Swama API for safe searches, which exclude unrated
+words. Databases created before content ratings were introduced must be
+reingested, or safe searches (the frontend's default) return nothing.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return reingest(cmd.Context(), app)
+		},
+	}
+
+	addModelFlag(cmd, "Models to generate missing embeddings for")
+
+	return cmd
+}
+
+func reingest(ctx context.Context, app *app) error {
+	cfg := app.cfg
+
+	sqlite, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	swama, err := cfg.SwamaAPI()
+	if err != nil {
+		return err
+	}
+
+	models, err := cfg.EnabledModels()
+	if err != nil {
+		return err
+	}
+
+	embedders, err := cfg.Embedders(false)
+	if err != nil {
+		return fmt.Errorf("creating embedders: %w", err)
+	}
+
+	// Variants can mostly be derived from their base model's embeddings,
+	// leaving only the features without those to be embedded below.
+	for _, model := range models {
+		if !model.IsVariant() {
+			continue
+		}
+
+		derived, err := sqlite.DeriveVariantEmbeddings(ctx, model)
+		if err != nil {
+			return fmt.Errorf("deriving %s embeddings: %w", model, err)
+		}
+
+		slog.InfoContext(
+			ctx,
+			"derived variant embeddings",
+			slog.String("model", model.String()),
+			slog.Int64("embeddings", derived),
+		)
+	}
+
+	rateLimiter := rate.NewLimiter(rate.Every(500*time.Millisecond), 1)
+
+	if err := classifyUnratedWords(ctx, sqlite, swama, rateLimiter); err != nil {
+		return err
+	}
+
+	for word, err := range sqlite.GetWords(ctx) {
+		if err := rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		slog.InfoContext(
+			ctx,
+			"reingesting definition",
+			slog.String("word", word.Word.Word),
+		)
+
+		if err != nil {
+			return fmt.Errorf("getting definitions: %w", err)
+		}
+
+		wordFeatures, err := sqlite.GetWordFeatures(ctx, word.ID)
+		if err != nil {
+			return fmt.Errorf("getting word features: %w", err)
+		}
+
+		// If we don't have any autogenerated features, generate some.
+		if !slices.ContainsFunc(wordFeatures, func(f backend.Feature) bool {
+			return f.Autogenerated
+		}) {
+			generatedDefs, err := swama.RephraseDefinition(ctx, word.Word)
+			if err != nil {
+				slog.ErrorContext(
+					ctx,
+					"rephrasing definition failed",
+					slog.String("word", word.Word.Word),
+					slog.Any("error", err),
+				)
+
+				continue
+			}
+
+			for _, generatedDef := range generatedDefs {
+				wordFeatures = append(wordFeatures, backend.Feature{
+					Embeddings:    map[backend.Model]backend.Embedding{},
+					Phrase:        generatedDef,
+					Autogenerated: true,
+				})
+			}
+		}
+
+		// Missing embeddings encodes all the (model, feature) pairs that are
+		// missing embeddings.
+		missingEmbeddings := make(map[backend.Model][]*backend.Feature)
+
+		for _, model := range models {
+			var features []*backend.Feature
+
+			for i := range wordFeatures {
+				if _, ok := wordFeatures[i].Embeddings[model]; !ok {
+					features = append(features, &wordFeatures[i])
+				}
+			}
+
+			// Only add models with missing features.
+			if len(features) > 0 {
+				missingEmbeddings[model] = features
+			}
+		}
+
+		for model, features := range missingEmbeddings {
+			featureStrings := make([]string, len(features))
+
+			for i, feature := range features {
+				featureStrings[i] = feature.Phrase
+			}
+
+			embedder, ok := embedders[model]
+			if !ok {
+				slog.ErrorContext(
+					ctx,
+					"missing embedder",
+					slog.String("model", model.String()),
+				)
+				continue
+			}
+
+			embeddings, err := embedder.Embed(ctx, featureStrings...)
+			if err != nil {
+				slog.ErrorContext(
+					ctx,
+					"embedding definitions failed",
+					slog.String("word", word.Word.Word),
+					slog.String("model", model.String()),
+					slog.Any("error", err),
+				)
+			}
+
+			for i, embedding := range embeddings {
+				features[i].Embeddings[model] = embedding
+			}
+		}
+
+		if err := sqlite.AddFeatures(ctx, word.ID, wordFeatures); err != nil {
+			return fmt.Errorf("adding word: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// classifyUnratedWords rates the content of the words which haven't been
+// classified yet. The words are collected first, as SQLite can't update the
+// words table while it is being read.
+func classifyUnratedWords(
+	ctx context.Context,
+	sqlite *backend.SQLiteVec,
+	swama *backend.SwamaAPI,
+	rateLimiter *rate.Limiter,
+) error {
+	var unrated []*backend.DBWord
+
+	for word, err := range sqlite.GetWords(ctx) {
+		if err != nil {
+			return fmt.Errorf("getting definitions: %w", err)
+		}
+
+		if word.ContentRating == backend.ContentRatingUnrated {
+			unrated = append(unrated, word)
+		}
+	}
+
+	for _, word := range unrated {
+		if err := rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		rating, err := swama.ClassifyContent(ctx, word.Word)
+		if err != nil {
+			slog.ErrorContext(
+				ctx,
+				"classifying content failed",
+				slog.String("word", word.Word.Word),
+				slog.Any("error", err),
+			)
+
+			continue
+		}
+
+		if err := sqlite.SetContentRating(ctx, word.ID, rating); err != nil {
+			return fmt.Errorf("setting content rating: %w", err)
+		}
+
+		slog.InfoContext(
+			ctx,
+			"classified content",
+			slog.String("word", word.Word.Word),
+			slog.String("rating", string(rating)),
+		)
+	}
+
+	return nil
+}
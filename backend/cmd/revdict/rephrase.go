@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+// ErrNoWords is returned by commands which need words in the database, when
+// there are none.
+var ErrNoWords = errors.New("the database has no words")
+
+func rephraseRandomWordCommand(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rephrase-random-word",
+		Short: "Rephrase the definition of a random word from the database",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return rephraseRandomWord(cmd.Context(), app)
+		},
+	}
+}
+
+// rephrasedWord is the JSON output of `rephrase-random-word`.
+type rephrasedWord struct {
+	backend.Word
+	Rephrased []string `json:"rephrased"`
+}
+
+func rephraseRandomWord(ctx context.Context, app *app) error {
+	vec, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	def, err := vec.GetRandomDefinition(ctx)
+	if err != nil {
+		return fmt.Errorf("getting random definition: %w", err)
+	}
+
+	if def == nil {
+		return ErrNoWords
+	}
+
+	swama, err := app.cfg.SwamaAPI()
+	if err != nil {
+		return err
+	}
+
+	rephrased, err := swama.RephraseDefinition(ctx, def.Word)
+	if err != nil {
+		return fmt.Errorf("rephrasing definition: %w", err)
+	}
+
+	result := rephrasedWord{
+		Word:      def.Word,
+		Rephrased: rephrased,
+	}
+
+	return app.output(result, func(w io.Writer) error {
+		fmt.Fprintf(
+			w,
+			"Random Definition:\nWord: %s\nDefinition: %s\nExample: %s\n",
+			def.Word.Word,
+			def.Definition,
+			def.Example,
+		)
+
+		fmt.Fprintf(w, "Rephrased:\n")
+
+		for i, sentence := range rephrased {
+			fmt.Fprintf(w, "Def. %d: %s\n", i+1, sentence)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+type searchArgs struct {
+	model string
+	limit int
+	safe  bool
+}
+
+func searchPhraseCommand(app *app) *cobra.Command {
+	var args searchArgs
+
+	cmd := &cobra.Command{
+		Use:     "search-phrase <phrase>",
+		Aliases: []string{"search"},
+		Short:   "Search the database for words matching a definition",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return searchPhrase(cmd.Context(), app, cmdArgs[0], args)
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&args.model,
+		"model",
+		backend.ModelQwen3Embedding8B4B_DWQ.String(),
+		"The model to use for embedding",
+	)
+	cmd.Flags().IntVarP(&args.limit, "limit", "n", 10, "Maximum number of results")
+	cmd.Flags().BoolVar(&args.safe, "safe", false, "Only return words rated safe for all audiences")
+	cmd.RegisterFlagCompletionFunc("model", completeModels)
+
+	return cmd
+}
+
+func searchPhrase(ctx context.Context, app *app, phrase string, args searchArgs) error {
+	model, err := backend.ModelFromString(args.model)
+	if err != nil {
+		return fmt.Errorf("parsing model flag: %w", err)
+	}
+
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	embedder, err := app.cfg.Embedder(model, true)
+	if err != nil {
+		return fmt.Errorf("creating embedder: %w", err)
+	}
+
+	embeddings, err := embedder.Embed(ctx, phrase)
+	if err != nil {
+		return fmt.Errorf("embedding phrase: %w", err)
+	}
+
+	embedding := embeddings[0]
+
+	slog.DebugContext(
+		ctx,
+		"embedded phrase",
+		slog.String("phrase", phrase),
+		slog.Int("embedding_size", len(embedding)),
+	)
+
+	relatedWords, err := db.RelatedWords(ctx, model, embedding, args.limit, args.safe)
+	if err != nil {
+		return fmt.Errorf("getting related words: %w", err)
+	}
+
+	return app.output(relatedWords, func(w io.Writer) error {
+		for _, word := range relatedWords {
+			fmt.Fprintf(w, "%s: %s (%.2f)\n", word.Word.Word, word.Word.Definition, word.Distance)
+			fmt.Fprintf(w, "\t-> %s\n", word.Phrase)
+		}
+
+		return nil
+	})
+}
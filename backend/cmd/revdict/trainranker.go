@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+type trainRankerArgs struct {
+	k              int
+	minSamples     int
+	minImprovement float64
+	force          bool
+	dryRun         bool
+}
+
+func trainRankerCommand(app *app) *cobra.Command {
+	var args trainRankerArgs
+
+	cmd := &cobra.Command{
+		Use:   "train-ranker",
+		Short: "Learn how to rank search results from user feedback",
+		Long: `Learn how to rank search results from user feedback.
+
+The query of each piece of feedback is searched for again, and a logistic
+calibration of each model's distances and the weights to fuse the models with
+are fitted to the verdicts. A fifth of the queries are held out, and the ranker
+is only activated if it ranks their feedback (by ROC AUC) at least as well as
+both distance alone and the currently active ranker.
+
+The ranker is stored even if it isn't activated, unless --dry-run is given.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return trainRanker(cmd.Context(), app, args)
+		},
+	}
+
+	cmd.Flags().IntVarP(&args.k, "k", "k", 10, "Number of results of each model to consider, which should match the API's search limit")
+	cmd.Flags().IntVar(&args.minSamples, "min-samples", 50, "Minimum number of feedback samples to train on")
+	cmd.Flags().Float64Var(&args.minImprovement, "min-improvement", 0, "Minimum improvement in held-out AUC over distance alone to activate the ranker")
+	cmd.Flags().BoolVar(&args.force, "force", false, "Activate the ranker even if it doesn't pass the evaluation")
+	cmd.Flags().BoolVar(&args.dryRun, "dry-run", false, "Evaluate the ranker without storing it")
+
+	addModelFlag(cmd, "Models to train the ranker for")
+
+	return cmd
+}
+
+// trainedRanker is the output of `train-ranker`.
+type trainedRanker struct {
+	*backend.Ranker
+	HeldOut int `json:"held_out"`
+	// ActiveAUC is the held-out AUC of the previously active ranker, if any.
+	ActiveAUC *float64 `json:"active_auc,omitempty"`
+	Activated bool     `json:"activated"`
+	// Reason explains why the ranker wasn't activated.
+	Reason string `json:"reason,omitempty"`
+}
+
+func trainRanker(ctx context.Context, app *app, args trainRankerArgs) error {
+	db, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	feedback, err := db.GetFeedback(ctx)
+	if err != nil {
+		return fmt.Errorf("getting feedback: %w", err)
+	}
+
+	embedders, err := app.cfg.Embedders(true)
+	if err != nil {
+		return fmt.Errorf("creating embedders: %w", err)
+	}
+
+	samples, err := backend.NewSearcher(embedders, db).RankerSamples(ctx, feedback, args.k)
+	if err != nil {
+		return err
+	}
+
+	if len(samples) < args.minSamples {
+		return fmt.Errorf("only %d feedback samples, at least %d are needed", len(samples), args.minSamples)
+	}
+
+	train, heldOut := backend.SplitRankerSamples(samples)
+
+	ranker, err := backend.TrainRanker(train)
+	if err != nil {
+		return fmt.Errorf("training ranker: %w", err)
+	}
+
+	result := trainedRanker{
+		Ranker:  ranker,
+		HeldOut: len(heldOut),
+	}
+
+	ranker.AUC, err = backend.RankerAUC(heldOut, ranker.ScoreSample)
+	if err != nil {
+		return fmt.Errorf("evaluating ranker on held-out feedback: %w", err)
+	}
+
+	ranker.BaselineAUC, err = backend.RankerAUC(heldOut, backend.BaselineScore)
+	if err != nil {
+		return fmt.Errorf("evaluating distance on held-out feedback: %w", err)
+	}
+
+	active, err := db.ActiveRanker(ctx)
+	if err == nil {
+		activeAUC, err := backend.RankerAUC(heldOut, active.ScoreSample)
+		if err != nil {
+			return fmt.Errorf("evaluating active ranker on held-out feedback: %w", err)
+		}
+
+		result.ActiveAUC = &activeAUC
+	} else if !errors.Is(err, backend.ErrNoActiveRanker) {
+		return fmt.Errorf("getting active ranker: %w", err)
+	}
+
+	result.Reason = ranker.Rejection(args.minImprovement, result.ActiveAUC)
+
+	result.Activated = result.Reason == "" || args.force
+
+	if !args.dryRun {
+		if err := db.AddRanker(ctx, ranker, result.Activated); err != nil {
+			return fmt.Errorf("storing ranker: %w", err)
+		}
+
+		slog.InfoContext(
+			ctx,
+			"stored ranker",
+			slog.Int64("id", ranker.ID),
+			slog.Bool("activated", result.Activated),
+		)
+	} else {
+		result.Activated = false
+	}
+
+	return app.output(result, func(out io.Writer) error {
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+		fmt.Fprintln(w, "MODEL\tINTERCEPT\tSLOPE\tWEIGHT")
+
+		for _, m := range ranker.Models {
+			fmt.Fprintf(w, "%s\t%.3f\t%.3f\t%.3f\n", m.Model, m.Intercept, m.Slope, m.Weight)
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "\nTrained on %d samples; evaluated on %d held-out samples.\n", ranker.Samples, result.HeldOut)
+		fmt.Fprintf(out, "Held-out AUC: %.3f (distance alone: %.3f", ranker.AUC, ranker.BaselineAUC)
+
+		if result.ActiveAUC != nil {
+			fmt.Fprintf(out, ", active ranker: %.3f", *result.ActiveAUC)
+		}
+
+		fmt.Fprintln(out, ")")
+
+		switch {
+		case args.dryRun:
+			fmt.Fprintln(out, "Dry run: the ranker was not stored.")
+		case result.Reason != "" && args.force:
+			fmt.Fprintf(out, "Activated ranker %d despite failing the evaluation: %s\n", ranker.ID, result.Reason)
+		case result.Activated:
+			fmt.Fprintf(out, "Activated ranker %d.\n", ranker.ID)
+		default:
+			fmt.Fprintf(out, "Stored ranker %d without activating it: %s\n", ranker.ID, result.Reason)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,124 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Confidence is whether a result is a close enough match to be trusted.
+type Confidence string
+
+const (
+	// ConfidenceHigh results are within their model's distance threshold.
+	ConfidenceHigh Confidence = "high"
+	// ConfidenceLow results are further than their model's distance threshold
+	// from the query, so are probably not what was searched for.
+	ConfidenceLow Confidence = "low"
+)
+
+// Confidences lists all the confidence levels.
+var Confidences = []Confidence{
+	ConfidenceHigh,
+	ConfidenceLow,
+}
+
+// DefaultDistanceThreshold is the distance threshold of models without one
+// configured.
+const DefaultDistanceThreshold = 0.5
+
+// DistanceThresholds are the largest cosine distances at which each model's
+// results are confident matches. Models' distances aren't comparable, so each
+// model has its own threshold, defaulting to [DefaultDistanceThreshold].
+type DistanceThresholds map[Model]float64
+
+// Threshold returns the distance threshold of the model.
+func (t DistanceThresholds) Threshold(model Model) float64 {
+	if threshold, ok := t[model]; ok {
+		return threshold
+	}
+
+	return DefaultDistanceThreshold
+}
+
+// Apply sets the confidence of each result, returning the overall confidence:
+// high if any model's results include a confident match.
+func (t DistanceThresholds) Apply(results map[Model][]SimilarDefinition) Confidence {
+	confidence := ConfidenceLow
+
+	for model, definitions := range results {
+		threshold := t.Threshold(model)
+
+		for i := range definitions {
+			if definitions[i].Distance <= threshold {
+				definitions[i].Confidence = ConfidenceHigh
+				confidence = ConfidenceHigh
+			} else {
+				definitions[i].Confidence = ConfidenceLow
+			}
+		}
+	}
+
+	return confidence
+}
+
+// closest returns the smallest distance of any result relative to its model's
+// threshold, so that results of different models can be compared. It is
+// infinite if there are no results.
+func (t DistanceThresholds) closest(results map[Model][]SimilarDefinition) float64 {
+	closest := math.Inf(1)
+
+	for model, definitions := range results {
+		if len(definitions) > 0 {
+			closest = min(closest, definitions[0].Distance/t.Threshold(model))
+		}
+	}
+
+	return closest
+}
+
+// HasWords reports whether there are any words in the DB.
+func (s *SQLiteVec) HasWords(ctx context.Context) (_ bool, err error) {
+	ctx, span := startSQLiteSpan(ctx, "HasWords")
+	defer func() { endSpan(span, err) }()
+
+	var exists bool
+
+	if err := s.db.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM words)`,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("checking for words: %w", err)
+	}
+
+	return exists, nil
+}
+
+// HasUnsafeWords reports whether there are any words in the DB which aren't
+// rated [ContentRatingSafe], and so are excluded from safe searches.
+func (s *SQLiteVec) HasUnsafeWords(ctx context.Context) (_ bool, err error) {
+	ctx, span := startSQLiteSpan(ctx, "HasUnsafeWords")
+	defer func() { endSpan(span, err) }()
+
+	var exists bool
+
+	if err := s.db.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM words WHERE content_rating != 'safe')`,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("checking for unsafe words: %w", err)
+	}
+
+	return exists, nil
+}
+
+// noResults reports whether no model returned any results.
+func noResults(results map[Model][]SimilarDefinition) bool {
+	for _, definitions := range results {
+		if len(definitions) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,423 @@
+// Package config loads the configuration shared by all the backend commands.
+//
+// Settings are resolved with the following precedence (highest first):
+//
+//  1. command-line flags;
+//  2. `REVDICT_`-prefixed environment variables (e.g. `REVDICT_DB_PATH` for
+//     `db.path`);
+//  3. the config file (`revdict.yaml`/`revdict.toml` in the working directory
+//     or `$XDG_CONFIG_HOME/revdict`, or the file passed with `--config`);
+//  4. the defaults below.
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/option"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+// EnvPrefix is the prefix of all environment variables read by the config.
+const EnvPrefix = "REVDICT"
+
+// Config keys.
+const (
+	KeyDBPath              = "db.path"
+	KeySwamaAddress        = "swama.address"
+	KeyOpenAIAPIKey        = "openai.api_key"
+	KeyOpenAIBaseURL       = "openai.base_url"
+	KeyModels              = "models"
+	KeyRateLimitPerSecond  = "rate_limit.per_second"
+	KeyRateLimitBurst      = "rate_limit.burst"
+	KeyRateLimitDailyQuota = "rate_limit.daily_quota"
+	KeyLogLevel            = "log.level"
+	KeyQueryLogMode        = "query_log.mode"
+	KeyQueryLogHashKey     = "query_log.hash_key"
+	KeyDistanceThresholds  = "distance_thresholds"
+)
+
+// Config holds the settings shared by all the backend commands.
+type Config struct {
+	DB        DBConfig        `mapstructure:"db"`
+	Swama     SwamaConfig     `mapstructure:"swama"`
+	OpenAI    OpenAIConfig    `mapstructure:"openai"`
+	Models    []string        `mapstructure:"models"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Log       LogConfig       `mapstructure:"log"`
+	QueryLog  QueryLogConfig  `mapstructure:"query_log"`
+	// DistanceThresholds are the largest distances at which each model's
+	// results are confident matches, keyed by model name.
+	DistanceThresholds map[string]float64 `mapstructure:"distance_thresholds"`
+}
+
+type DBConfig struct {
+	// Path is the path to the SQLite database.
+	Path string `mapstructure:"path"`
+}
+
+type SwamaConfig struct {
+	// Address is the base URL of the Swama API server.
+	Address string `mapstructure:"address"`
+}
+
+type OpenAIConfig struct {
+	// APIKey overrides the `OPENAI_API_KEY` environment variable.
+	APIKey string `mapstructure:"api_key"`
+	// BaseURL overrides the `OPENAI_BASE_URL` environment variable.
+	BaseURL string `mapstructure:"base_url"`
+}
+
+type RateLimitConfig struct {
+	// PerSecond is the sustained number of requests per second allowed per
+	// client. Zero disables rate limiting.
+	PerSecond float64 `mapstructure:"per_second"`
+	// Burst is the maximum burst of requests allowed per client.
+	Burst int `mapstructure:"burst"`
+	// DailyQuota is the maximum number of requests per API key per day. Zero
+	// disables the quota.
+	DailyQuota int64 `mapstructure:"daily_quota"`
+}
+
+type LogConfig struct {
+	// Level is the minimum level of log messages (debug, info, warn, error).
+	Level string `mapstructure:"level"`
+}
+
+type QueryLogConfig struct {
+	// Mode is whether searches are logged for analytics: not at all (off),
+	// with only a hash of the query (hashed), or with the query (raw).
+	Mode string `mapstructure:"mode"`
+	// HashKey is the secret key the queries are hashed with. It is required in
+	// the hashed mode.
+	HashKey string `mapstructure:"hash_key"`
+}
+
+// defaults are the values used when a setting isn't configured anywhere.
+//
+// Every key must have a default, as viper only reads environment variables for
+// keys it knows about.
+var defaults = map[string]any{
+	KeyDBPath:              "words.db",
+	KeySwamaAddress:        "http://localhost:28100",
+	KeyOpenAIAPIKey:        "",
+	KeyOpenAIBaseURL:       "",
+	KeyModels:              []string{},
+	KeyRateLimitPerSecond:  1.0,
+	KeyRateLimitBurst:      10,
+	KeyRateLimitDailyQuota: 0,
+	KeyLogLevel:            "info",
+	KeyQueryLogMode:        string(backend.QueryLogOff),
+	KeyQueryLogHashKey:     "",
+	KeyDistanceThresholds:  map[string]float64{},
+}
+
+// flagAnnotation marks a flag as setting the config key in its annotation
+// value.
+const flagAnnotation = "revdict_config_key"
+
+// BindFlag maps the named flag of the command onto a config key, so that
+// setting the flag overrides the config.
+func BindFlag(flags *pflag.FlagSet, flagName string, key string) {
+	if err := flags.SetAnnotation(flagName, flagAnnotation, []string{key}); err != nil {
+		panic(fmt.Sprintf("binding flag --%s to config: %v", flagName, err))
+	}
+}
+
+// AddFlags registers the shared flags on the command, so that they are
+// available to it and all of its subcommands.
+//
+// The defaults shown are only informational: unset flags fall through to the
+// environment and config file.
+func AddFlags(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+
+	flags.String("config", "", "Path to a config file (YAML or TOML)")
+	flags.String("db", defaults[KeyDBPath].(string), "Path to the SQLite database")
+	flags.String("swama-address", defaults[KeySwamaAddress].(string), "Address of the Swama API server")
+	flags.String("openai-base-url", "", "Base URL of the OpenAI API")
+	flags.String("log-level", defaults[KeyLogLevel].(string), "Minimum log level (debug, info, warn, error)")
+
+	BindFlag(flags, "db", KeyDBPath)
+	BindFlag(flags, "swama-address", KeySwamaAddress)
+	BindFlag(flags, "openai-base-url", KeyOpenAIBaseURL)
+	BindFlag(flags, "log-level", KeyLogLevel)
+}
+
+// Load resolves the config for the given command, using the flags bound with
+// [BindFlag].
+func Load(cmd *cobra.Command) (*Config, error) {
+	v := viper.New()
+
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	var bindErr error
+
+	bind := func(flag *pflag.Flag) {
+		keys, ok := flag.Annotations[flagAnnotation]
+		if !ok || bindErr != nil {
+			return
+		}
+
+		if err := v.BindPFlag(keys[0], flag); err != nil {
+			bindErr = fmt.Errorf("binding flag --%s: %w", flag.Name, err)
+		}
+	}
+
+	cmd.InheritedFlags().VisitAll(bind)
+	cmd.LocalFlags().VisitAll(bind)
+
+	if bindErr != nil {
+		return nil, bindErr
+	}
+
+	if err := readConfigFile(v, cmd); err != nil {
+		return nil, err
+	}
+
+	var config Config
+
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("decoding config: %w", err)
+	}
+
+	return &config, nil
+}
+
+func readConfigFile(v *viper.Viper, cmd *cobra.Command) error {
+	var configFile string
+
+	if flag := cmd.Flags().Lookup("config"); flag != nil {
+		configFile = flag.Value.String()
+	}
+
+	if configFile == "" {
+		configFile = os.Getenv(EnvPrefix + "_CONFIG")
+	}
+
+	if configFile != "" {
+		v.SetConfigFile(configFile)
+	} else {
+		v.SetConfigName("revdict")
+		v.AddConfigPath(".")
+
+		if configDir, err := os.UserConfigDir(); err == nil {
+			v.AddConfigPath(filepath.Join(configDir, "revdict"))
+		}
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+
+		// The config file is optional unless explicitly specified.
+		if errors.As(err, &notFound) {
+			return nil
+		}
+
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	return nil
+}
+
+// SetupLogging installs the default logger at the configured level.
+func (c *Config) SetupLogging() error {
+	var level slog.Level
+
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		return fmt.Errorf("parsing log level: %w", err)
+	}
+
+	slog.SetDefault(
+		slog.New(
+			slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+				Level: level,
+			}),
+		),
+	)
+
+	return nil
+}
+
+// OpenDB opens the configured database, registering any truncated variants
+// among the enabled models.
+func (c *Config) OpenDB(ctx context.Context) (*backend.SQLiteVec, error) {
+	models, err := c.EnabledModels()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := backend.NewSQLiteVec(ctx, c.DB.Path)
+	if err != nil {
+		return nil, fmt.Errorf("creating SQLiteVec: %w", err)
+	}
+
+	if err := db.RegisterModels(ctx, models); err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("registering models: %w", err)
+	}
+
+	return db, nil
+}
+
+// SwamaAPI creates a client for the configured Swama server.
+func (c *Config) SwamaAPI() (*backend.SwamaAPI, error) {
+	swamaURL, err := url.Parse(c.Swama.Address)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Swama address: %w", err)
+	}
+
+	swama, err := backend.NewSwamaAPI(*swamaURL)
+	if err != nil {
+		return nil, fmt.Errorf("creating SwamaAPI: %w", err)
+	}
+
+	return swama, nil
+}
+
+// OpenAIOptions returns the client options for the configured OpenAI API.
+func (c *Config) OpenAIOptions() []option.RequestOption {
+	var opts []option.RequestOption
+
+	if c.OpenAI.APIKey != "" {
+		opts = append(opts, option.WithAPIKey(c.OpenAI.APIKey))
+	}
+
+	if c.OpenAI.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(c.OpenAI.BaseURL))
+	}
+
+	return opts
+}
+
+// EnabledModels returns the configured models, defaulting to all of
+// [backend.Models] if none are configured.
+func (c *Config) EnabledModels() ([]backend.Model, error) {
+	if len(c.Models) == 0 {
+		return backend.Models, nil
+	}
+
+	models := make([]backend.Model, 0, len(c.Models))
+
+	for _, name := range c.Models {
+		model, err := backend.ModelFromString(name)
+		if err != nil {
+			return nil, fmt.Errorf("parsing model %q: %w", name, err)
+		}
+
+		models = append(models, model)
+	}
+
+	return models, nil
+}
+
+// Embedder creates the [backend.Embedder] for the given model. Query
+// embedders embed search queries, while document embedders embed definitions.
+func (c *Config) Embedder(model backend.Model, query bool) (backend.Embedder, error) {
+	switch model.Base() {
+	case backend.ModelQwen3Embedding8B4B_DWQ:
+		swama, err := c.SwamaAPI()
+		if err != nil {
+			return nil, err
+		}
+
+		var embedder backend.Embedder
+
+		if query {
+			embedder = backend.NewSwamaQueryEmbedder(swama)
+		} else {
+			embedder = backend.NewSwamaEmbedder(swama)
+		}
+
+		// Swama has no way to request fewer dimensions, so truncate locally.
+		if model.IsVariant() {
+			embedder = backend.NewTruncatingEmbedder(embedder, model.Dimensions())
+		}
+
+		return embedder, nil
+	case backend.ModelOpenAITextEmbedding3Large:
+		var dimensions int
+
+		if model.IsVariant() {
+			dimensions = model.Dimensions()
+		}
+
+		return backend.NewOpenAIEmbedder(
+			openai.EmbeddingModelTextEmbedding3Large,
+			dimensions,
+			c.OpenAIOptions()...,
+		), nil
+	}
+
+	return nil, fmt.Errorf("model %s not supported yet", model)
+}
+
+// Thresholds returns the configured distance thresholds of the models.
+func (c *Config) Thresholds() (backend.DistanceThresholds, error) {
+	thresholds := make(backend.DistanceThresholds, len(c.DistanceThresholds))
+
+	for name, threshold := range c.DistanceThresholds {
+		model, err := backend.ModelFromString(name)
+		if err != nil {
+			return nil, fmt.Errorf("parsing distance thresholds: %w", err)
+		}
+
+		thresholds[model] = threshold
+	}
+
+	return thresholds, nil
+}
+
+// Embedders creates the embedders for all the enabled models.
+func (c *Config) Embedders(query bool) (backend.Embedders, error) {
+	models, err := c.EnabledModels()
+	if err != nil {
+		return nil, err
+	}
+
+	embedders := make(backend.Embedders, len(models))
+
+	for _, model := range models {
+		embedder, err := c.Embedder(model, query)
+		if err != nil {
+			return nil, err
+		}
+
+		embedders[model] = embedder
+	}
+
+	return embedders, nil
+}
+
+// Setup loads the config for the command (see [Load]), and installs the
+// configured logger.
+func Setup(cmd *cobra.Command) (*Config, error) {
+	config, err := Load(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	if err := config.SetupLogging(); err != nil {
+		return nil, fmt.Errorf("setting up logging: %w", err)
+	}
+
+	return config, nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+// loadExample loads the example config, with the commented-out settings
+// uncommented by the replacer.
+func loadExample(t *testing.T, uncomment *strings.Replacer) *Config {
+	t.Helper()
+
+	example, err := os.ReadFile("../revdict.example.yaml")
+	if err != nil {
+		t.Fatalf("reading example config: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "revdict.yaml")
+
+	if err := os.WriteFile(path, []byte(uncomment.Replace(string(example))), 0o600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	AddFlags(cmd)
+
+	if err := cmd.ParseFlags([]string{"--config", path}); err != nil {
+		t.Fatalf("parsing flags: %v", err)
+	}
+
+	config, err := Load(cmd)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+
+	return config
+}
+
+func TestLoadExample(t *testing.T) {
+	config := loadExample(t, strings.NewReplacer(
+		"# distance_thresholds:", "distance_thresholds:",
+		"#   ", "  ",
+	))
+
+	models, err := config.EnabledModels()
+	if err != nil {
+		t.Fatalf("parsing models: %v", err)
+	}
+
+	if len(models) != 2 {
+		t.Errorf("got models %v, expected the two in the example", models)
+	}
+
+	thresholds, err := config.Thresholds()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := backend.DistanceThresholds{
+		backend.ModelQwen3Embedding8B4B_DWQ:    0.5,
+		backend.ModelOpenAITextEmbedding3Large: 0.6,
+	}
+
+	if len(thresholds) != len(expected) {
+		t.Fatalf("got thresholds %v, expected %v", thresholds, expected)
+	}
+
+	for model, threshold := range expected {
+		if thresholds[model] != threshold {
+			t.Errorf("%s threshold is %v, expected %v", model, thresholds[model], threshold)
+		}
+	}
+}
+
+func TestThresholdsOfVariants(t *testing.T) {
+	config := loadExample(t, strings.NewReplacer(
+		"# distance_thresholds:", "distance_thresholds:",
+		"#   openai/text-embedding-3-large:", "  openai/text-embedding-3-large@256:",
+		"#   ", "  ",
+	))
+
+	thresholds, err := config.Thresholds()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variant, err := backend.Variant(backend.ModelOpenAITextEmbedding3Large, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if thresholds[variant] != 0.6 {
+		t.Errorf("got thresholds %v, expected 0.6 for %s", thresholds, variant)
+	}
+}
@@ -0,0 +1,129 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ContentRating classifies whether a word and its definition are suitable for
+// all audiences. Urban Dictionary content is often explicit, so searches can
+// be restricted to words rated safe.
+type ContentRating string
+
+const (
+	// ContentRatingUnrated is the rating of words which haven't been
+	// classified yet. They are excluded from safe searches.
+	ContentRatingUnrated ContentRating = "unrated"
+	// ContentRatingSafe is suitable for all audiences.
+	ContentRatingSafe ContentRating = "safe"
+	// ContentRatingExplicit is sexual, graphic or drug-related.
+	ContentRatingExplicit ContentRating = "explicit"
+	// ContentRatingOffensive is derogatory, e.g. slurs or hate speech.
+	ContentRatingOffensive ContentRating = "offensive"
+)
+
+// ContentRatings lists all the content ratings, from the least to the most
+// severe.
+var ContentRatings = []ContentRating{
+	ContentRatingUnrated,
+	ContentRatingSafe,
+	ContentRatingExplicit,
+	ContentRatingOffensive,
+}
+
+func ContentRatingFromString(s string) (ContentRating, error) {
+	for _, rating := range ContentRatings {
+		if string(rating) == s {
+			return rating, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown content rating: %s", s)
+}
+
+// ErrNoContentRating is returned when the classifier's output doesn't contain
+// a content rating.
+var ErrNoContentRating = errors.New("no content rating found in classifier output")
+
+// ClassifyContent rates whether a word and its definition are suitable for
+// all audiences, using the Swama API.
+func (s *SwamaAPI) ClassifyContent(ctx context.Context, word Word) (ContentRating, error) {
+	output, err := s.Complete(
+		ctx,
+		"Classify the following slang word, definition and example for a content filter. Answer with exactly one label: \"safe\" if it is suitable for all audiences, \"explicit\" if it is sexual, graphic or about drugs, or \"offensive\" if it is derogatory, e.g. slurs or hate speech. If both explicit and offensive, answer \"offensive\". Output only the label.",
+		fmt.Sprintf("Word: %s\nDefinition:\n%s\nExample:\n%s\n", word.Word, word.Definition, word.Example),
+	)
+	if err != nil {
+		return "", fmt.Errorf("classifying content: %w", err)
+	}
+
+	return parseContentRating(PruneThinking(output))
+}
+
+// parseContentRating reads the label from the classifier's output. If the
+// model didn't output only the label, the most severe label mentioned is
+// taken, so that e.g. "not safe: explicit" isn't rated safe.
+func parseContentRating(output string) (ContentRating, error) {
+	labels := strings.FieldsFunc(strings.ToLower(output), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	// ContentRatings is ordered by severity, and unrated isn't a label.
+	severity := 0
+
+	for _, label := range labels {
+		severity = max(severity, slices.Index(ContentRatings, ContentRating(label)))
+	}
+
+	if severity == 0 {
+		return "", ErrNoContentRating
+	}
+
+	return ContentRatings[severity], nil
+}
+
+// SetContentRating sets the content rating of a word.
+func (s *SQLiteVec) SetContentRating(
+	ctx context.Context,
+	wordID int64,
+	rating ContentRating,
+) (err error) {
+	ctx, span := startSQLiteSpan(
+		ctx,
+		"SetContentRating",
+		attribute.Int64("word_id", wordID),
+		attribute.String("rating", string(rating)),
+	)
+	defer func() { endSpan(span, err) }()
+
+	result, err := s.db.ExecContext(
+		ctx,
+		`
+			UPDATE words
+			SET content_rating = ?
+			WHERE id = ?
+		`,
+		rating,
+		wordID,
+	)
+	if err != nil {
+		return fmt.Errorf("updating content rating: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking updated content rating: %w", err)
+	}
+
+	if updated == 0 {
+		return ErrWordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package backend
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseContentRating(t *testing.T) {
+	tests := []struct {
+		output string
+		rating ContentRating
+		err    error
+	}{
+		{output: "safe", rating: ContentRatingSafe},
+		{output: " Explicit.\n", rating: ContentRatingExplicit},
+		{output: `"offensive"`, rating: ContentRatingOffensive},
+		{output: "Not safe: explicit", rating: ContentRatingExplicit},
+		{output: "It is safe to say this is offensive", rating: ContentRatingOffensive},
+		{output: "explicit, but not offensive", rating: ContentRatingOffensive},
+		{output: "unrated", err: ErrNoContentRating},
+		{output: "I can't classify this.", err: ErrNoContentRating},
+		{output: "", err: ErrNoContentRating},
+	}
+
+	for _, tt := range tests {
+		rating, err := parseContentRating(tt.output)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%q: got error %v, expected %v", tt.output, err, tt.err)
+		}
+
+		if rating != tt.rating {
+			t.Errorf("%q: got rating %q, expected %q", tt.output, rating, tt.rating)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package backend
 
 import (
+	"math"
 	"regexp"
 	"strings"
 
@@ -14,6 +15,9 @@ type Word struct {
 	Author     string `json:"author"`
 	Definition string `json:"definition"`
 	Example    string `json:"example"`
+	// ContentRating is whether the word is suitable for all audiences. Words
+	// are [ContentRatingUnrated] until classified.
+	ContentRating ContentRating `json:"content_rating,omitempty"`
 }
 
 type Definition struct {
@@ -30,9 +34,41 @@ type Feature struct {
 type Embedding []float32
 
 type SimilarDefinition struct {
-	Word     `json:"definition"`
+	// ID is the ID of the word, for looking up its details.
+	ID int64 `json:"id"`
+	// Word is named, rather than embedded, so that the OpenAPI schema matches
+	// its JSON encoding.
+	Word     Word    `json:"definition"`
 	Distance float64 `json:"distance"`
 	Phrase   string  `json:"phrase"`
+	// Score is the probability of the word being relevant, according to the
+	// active [Ranker]. It is omitted if no ranker is active.
+	Score float64 `json:"score,omitempty"`
+	// Confidence is whether the word is within its model's distance
+	// threshold.
+	Confidence Confidence `json:"confidence,omitempty" enum:"high,low"`
+}
+
+// Normalize returns the embedding scaled to unit length.
+func (e Embedding) Normalize() Embedding {
+	var sum float64
+
+	for _, v := range e {
+		sum += float64(v) * float64(v)
+	}
+
+	norm := math.Sqrt(sum)
+	normalized := make(Embedding, len(e))
+
+	if norm == 0 {
+		return normalized
+	}
+
+	for i, v := range e {
+		normalized[i] = float32(float64(v) / norm)
+	}
+
+	return normalized
 }
 
 func NewEmbeddingFromFloat64(vector []float64) Embedding {
@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+)
+
+// DistanceMetric is a way of comparing two embeddings.
+type DistanceMetric string
+
+const (
+	// DistanceCosine is one minus the cosine similarity, as used for searches.
+	DistanceCosine DistanceMetric = "cosine"
+	// DistanceL2 is the Euclidean distance.
+	DistanceL2 DistanceMetric = "l2"
+	// DistanceDot is the dot product. Unlike the other metrics, it is a
+	// similarity: higher is closer.
+	DistanceDot DistanceMetric = "dot"
+)
+
+// DistanceMetrics lists all the supported distance metrics.
+var DistanceMetrics = []DistanceMetric{
+	DistanceCosine,
+	DistanceL2,
+	DistanceDot,
+}
+
+func DistanceMetricFromString(s string) (DistanceMetric, error) {
+	for _, metric := range DistanceMetrics {
+		if string(metric) == s {
+			return metric, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown distance metric: %s", s)
+}
+
+// Distance compares two embeddings, which must have the same number of
+// dimensions.
+func (m DistanceMetric) Distance(a Embedding, b Embedding) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf(
+			"cannot compare embeddings with %d and %d dimensions",
+			len(a),
+			len(b),
+		)
+	}
+
+	var dot, normA, normB, squaredDiff float64
+
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+
+		dot += x * y
+		normA += x * x
+		normB += y * y
+		squaredDiff += (x - y) * (x - y)
+	}
+
+	switch m {
+	case DistanceCosine:
+		if normA == 0 || normB == 0 {
+			return 0, fmt.Errorf("cannot compute cosine distance of a zero embedding")
+		}
+
+		return 1 - dot/math.Sqrt(normA*normB), nil
+	case DistanceL2:
+		return math.Sqrt(squaredDiff), nil
+	case DistanceDot:
+		return dot, nil
+	}
+
+	return 0, fmt.Errorf("unknown distance metric: %s", m)
+}
@@ -1,11 +1,18 @@
 package backend
 
 import (
+	"container/list"
 	"context"
 	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/option"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/time/rate"
 )
 
@@ -14,9 +21,30 @@ type Embedder interface {
 	Embed(ctx context.Context, phrases ...string) ([]Embedding, error)
 }
 
+// Prober is implemented by [Embedder]s that can cheaply check whether their
+// backing service is reachable, without embedding anything.
+type Prober interface {
+	Probe(ctx context.Context) error
+}
+
 // Embedders represents a collection of embedding services.
 type Embedders map[Model]Embedder
 
+// Probe checks each embedding service that implements [Prober].
+//
+// Returns a map between the backend and the result of its probe.
+func (e *Embedders) Probe(ctx context.Context) map[Model]error {
+	results := make(map[Model]error, len(*e))
+
+	for model, embedder := range *e {
+		if prober, ok := embedder.(Prober); ok {
+			results[model] = prober.Probe(ctx)
+		}
+	}
+
+	return results
+}
+
 // Embed runs an embedding request against all configured embedding services.
 //
 // Returns a map between the backend and its embeddings.
@@ -24,8 +52,25 @@ func (e *Embedders) Embed(ctx context.Context, phrases ...string) (map[Model][]E
 	embeddings := make(map[Model][]Embedding, len(*e))
 
 	for model, embedder := range *e {
-		embedding, err := embedder.Embed(ctx, phrases...)
+		spanCtx, span := tracer.Start(
+			ctx,
+			"Embedder.Embed",
+			trace.WithAttributes(
+				attribute.String("model", model.String()),
+				attribute.Int("phrases", len(phrases)),
+			),
+		)
+
+		start := time.Now()
+
+		embedding, err := embedder.Embed(spanCtx, phrases...)
+
+		embeddingDuration.WithLabelValues(model.String()).Observe(time.Since(start).Seconds())
+		endSpan(span, err)
+
 		if err != nil {
+			embeddingErrors.WithLabelValues(model.String()).Inc()
+
 			return nil, err
 		}
 
@@ -41,7 +86,10 @@ type swamaEmbedder struct {
 	api *SwamaAPI
 }
 
-var _ Embedder = &swamaEmbedder{}
+var (
+	_ Embedder = &swamaEmbedder{}
+	_ Prober   = &swamaEmbedder{}
+)
 
 func NewSwamaEmbedder(api *SwamaAPI) Embedder {
 	return &swamaEmbedder{
@@ -49,6 +97,11 @@ func NewSwamaEmbedder(api *SwamaAPI) Embedder {
 	}
 }
 
+// Probe checks that the Swama API is reachable.
+func (s *swamaEmbedder) Probe(ctx context.Context) error {
+	return s.api.Probe(ctx)
+}
+
 // Embed returns the embeddings for the given phrases from the Swama API.
 func (s *swamaEmbedder) Embed(ctx context.Context, phrases ...string) ([]Embedding, error) {
 	// Call the Swama API to get embeddings for the phrases
@@ -73,7 +126,10 @@ type swamaQueryEmbedder struct {
 
 // swamaQueryEmbedder is an implementation of the [Embedder] interface for the
 // Swama API.
-var _ Embedder = &swamaQueryEmbedder{}
+var (
+	_ Embedder = &swamaQueryEmbedder{}
+	_ Prober   = &swamaQueryEmbedder{}
+)
 
 func NewSwamaQueryEmbedder(api *SwamaAPI) Embedder {
 	return &swamaQueryEmbedder{
@@ -81,6 +137,11 @@ func NewSwamaQueryEmbedder(api *SwamaAPI) Embedder {
 	}
 }
 
+// Probe checks that the Swama API is reachable.
+func (s *swamaQueryEmbedder) Probe(ctx context.Context) error {
+	return s.api.Probe(ctx)
+}
+
 // Embed returns the embeddings for the given search phrases from the Swama API.
 func (s *swamaQueryEmbedder) Embed(
 	ctx context.Context,
@@ -105,19 +166,53 @@ func (s *swamaQueryEmbedder) Embed(
 // openaiEmbedder is an implementation of the [Embedder] interface for the
 // OpenAI API.
 type openaiEmbedder struct {
-	api       openai.Client
-	model     openai.EmbeddingModel
-	ratelimit rate.Limiter
+	api        openai.Client
+	model      openai.EmbeddingModel
+	dimensions int
+	ratelimit  rate.Limiter
 }
 
-var _ Embedder = &openaiEmbedder{}
+var (
+	_ Embedder = &openaiEmbedder{}
+	_ Prober   = &openaiEmbedder{}
+)
+
+// NewOpenAIEmbedder creates an [Embedder] for the given OpenAI model. If
+// `dimensions` is non-zero, the API is asked to shorten the embeddings to that
+// many dimensions.
+//
+// The client reads its API key and base URL from the environment by default,
+// which may be overridden with the given options.
+func NewOpenAIEmbedder(
+	model openai.EmbeddingModel,
+	dimensions int,
+	opts ...option.RequestOption,
+) Embedder {
+	opts = append(
+		[]option.RequestOption{
+			option.WithHTTPClient(&http.Client{
+				Transport: otelhttp.NewTransport(http.DefaultTransport),
+			}),
+		},
+		opts...,
+	)
 
-func NewOpenAIEmbedder(model openai.EmbeddingModel) Embedder {
 	return &openaiEmbedder{
-		api:       openai.NewClient(),
-		model:     model,
-		ratelimit: *rate.NewLimiter(rate.Every(500*time.Millisecond), 5),
+		api:        openai.NewClient(opts...),
+		model:      model,
+		dimensions: dimensions,
+		ratelimit:  *rate.NewLimiter(rate.Every(500*time.Millisecond), 5),
+	}
+}
+
+// Probe checks that the OpenAI API is reachable and the model is available,
+// without spending any tokens.
+func (o *openaiEmbedder) Probe(ctx context.Context) error {
+	if _, err := o.api.Models.Get(ctx, o.model); err != nil {
+		return fmt.Errorf("getting OpenAI model: %w", err)
 	}
+
+	return nil
 }
 
 // Embed returns the embeddings for the given phrases from the OpenAI API.
@@ -126,20 +221,26 @@ func (o *openaiEmbedder) Embed(ctx context.Context, phrases ...string) ([]Embedd
 		return nil, fmt.Errorf("waiting for rate limit: %w", err)
 	}
 
-	// Call the OpenAI API to get embeddings for the phrases.
-	openaiEmbeddings, err := o.api.Embeddings.New(
-		ctx,
-		openai.EmbeddingNewParams{
-			Model: o.model,
-			Input: openai.EmbeddingNewParamsInputUnion{
-				OfArrayOfStrings: phrases,
-			},
+	params := openai.EmbeddingNewParams{
+		Model: o.model,
+		Input: openai.EmbeddingNewParamsInputUnion{
+			OfArrayOfStrings: phrases,
 		},
-	)
+	}
+
+	if o.dimensions > 0 {
+		params.Dimensions = openai.Int(int64(o.dimensions))
+	}
+
+	// Call the OpenAI API to get embeddings for the phrases.
+	openaiEmbeddings, err := o.api.Embeddings.New(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("requesting OpenAI embeddings: %w", err)
 	}
 
+	openaiTokens.WithLabelValues(o.model, "prompt").Add(float64(openaiEmbeddings.Usage.PromptTokens))
+	openaiTokens.WithLabelValues(o.model, "total").Add(float64(openaiEmbeddings.Usage.TotalTokens))
+
 	embeddings := make([]Embedding, 0, len(openaiEmbeddings.Data))
 
 	for _, data := range openaiEmbeddings.Data {
@@ -148,3 +249,182 @@ func (o *openaiEmbedder) Embed(ctx context.Context, phrases ...string) ([]Embedd
 
 	return embeddings, nil
 }
+
+// truncatingEmbedder is an implementation of the [Embedder] interface which
+// shortens the embeddings of a Matryoshka-trained model to their leading
+// dimensions, renormalised to unit length.
+type truncatingEmbedder struct {
+	embedder   Embedder
+	dimensions int
+}
+
+var (
+	_ Embedder = &truncatingEmbedder{}
+	_ Prober   = &truncatingEmbedder{}
+)
+
+// NewTruncatingEmbedder wraps an [Embedder] so that its embeddings are
+// truncated to the given number of dimensions.
+func NewTruncatingEmbedder(embedder Embedder, dimensions int) Embedder {
+	return &truncatingEmbedder{
+		embedder:   embedder,
+		dimensions: dimensions,
+	}
+}
+
+// Probe checks the wrapped [Embedder], if it supports probing.
+func (t *truncatingEmbedder) Probe(ctx context.Context) error {
+	if prober, ok := t.embedder.(Prober); ok {
+		return prober.Probe(ctx)
+	}
+
+	return nil
+}
+
+// Embed returns the truncated embeddings for the given phrases.
+func (t *truncatingEmbedder) Embed(ctx context.Context, phrases ...string) ([]Embedding, error) {
+	embeddings, err := t.embedder.Embed(ctx, phrases...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, embedding := range embeddings {
+		if len(embedding) < t.dimensions {
+			return nil, fmt.Errorf(
+				"cannot truncate %d-dimensional embedding to %d dimensions",
+				len(embedding),
+				t.dimensions,
+			)
+		}
+
+		embeddings[i] = embedding[:t.dimensions].Normalize()
+	}
+
+	return embeddings, nil
+}
+
+// cachingEmbedder is an implementation of the [Embedder] interface that keeps
+// the most recently used embeddings in memory, so that repeated phrases (e.g.
+// popular queries) don't need to be re-embedded.
+type cachingEmbedder struct {
+	model    Model
+	embedder Embedder
+	size     int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// cacheEntry is an element of the [cachingEmbedder] LRU list.
+type cacheEntry struct {
+	phrase    string
+	embedding Embedding
+}
+
+var (
+	_ Embedder = &cachingEmbedder{}
+	_ Prober   = &cachingEmbedder{}
+)
+
+// NewCachingEmbedder wraps an [Embedder] for the given model with an LRU cache
+// holding up to `size` embeddings.
+func NewCachingEmbedder(model Model, embedder Embedder, size int) Embedder {
+	return &cachingEmbedder{
+		model:    model,
+		embedder: embedder,
+		size:     size,
+		entries:  make(map[string]*list.Element, size),
+		order:    list.New(),
+	}
+}
+
+// Probe checks the wrapped [Embedder], if it supports probing.
+func (c *cachingEmbedder) Probe(ctx context.Context) error {
+	if prober, ok := c.embedder.(Prober); ok {
+		return prober.Probe(ctx)
+	}
+
+	return nil
+}
+
+// Embed returns the cached embeddings for the given phrases, embedding only
+// those that are missing from the cache.
+func (c *cachingEmbedder) Embed(ctx context.Context, phrases ...string) ([]Embedding, error) {
+	embeddings := make([]Embedding, len(phrases))
+
+	var (
+		missing        []string
+		missingIndices []int
+	)
+
+	c.mu.Lock()
+
+	for i, phrase := range phrases {
+		if element, ok := c.entries[phrase]; ok {
+			c.order.MoveToFront(element)
+			embeddings[i] = element.Value.(*cacheEntry).embedding
+
+			continue
+		}
+
+		missing = append(missing, phrase)
+		missingIndices = append(missingIndices, i)
+	}
+
+	c.mu.Unlock()
+
+	embeddingCacheRequests.WithLabelValues(c.model.String(), "hit").Add(float64(len(phrases) - len(missing)))
+	embeddingCacheRequests.WithLabelValues(c.model.String(), "miss").Add(float64(len(missing)))
+
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+
+	missingEmbeddings, err := c.embedder.Embed(ctx, missing...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(missingEmbeddings) != len(missing) {
+		return nil, fmt.Errorf(
+			"expected %d embeddings, got %d",
+			len(missing),
+			len(missingEmbeddings),
+		)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, embedding := range missingEmbeddings {
+		embeddings[missingIndices[i]] = embedding
+		c.add(missing[i], embedding)
+	}
+
+	return embeddings, nil
+}
+
+// add inserts an embedding into the cache, evicting the least recently used
+// entry if the cache is full.
+//
+// Must be called with the lock held.
+func (c *cachingEmbedder) add(phrase string, embedding Embedding) {
+	if element, ok := c.entries[phrase]; ok {
+		element.Value.(*cacheEntry).embedding = embedding
+		c.order.MoveToFront(element)
+
+		return
+	}
+
+	c.entries[phrase] = c.order.PushFront(&cacheEntry{
+		phrase:    phrase,
+		embedding: embedding,
+	})
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).phrase)
+	}
+}
@@ -0,0 +1,325 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrUnknownModel is returned when a model has no entry in the
+// `embedding_models` table.
+var ErrUnknownModel = errors.New("model not known to the database")
+
+// DimensionMismatchError is returned when an embedding does not have the
+// number of dimensions recorded for its model. Storing or querying such an
+// embedding would give meaningless distances.
+type DimensionMismatchError struct {
+	Model    Model
+	Expected int
+	Actual   int
+}
+
+func (e *DimensionMismatchError) Error() string {
+	return fmt.Sprintf(
+		"embedding for model %s has %d dimensions, expected %d",
+		e.Model,
+		e.Actual,
+		e.Expected,
+	)
+}
+
+// modelMetadata is the per-model configuration recorded in the
+// `embedding_models` table.
+type modelMetadata struct {
+	dimensions   int
+	quantization Quantization
+	// fullPrecision is whether the model's float32 embeddings are kept. They
+	// may be dropped from quantized models to save space.
+	fullPrecision bool
+}
+
+// loadModelMetadata reads the configuration of each model from the
+// `embedding_models` table.
+func (s *SQLiteVec) loadModelMetadata(ctx context.Context) error {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+			SELECT id, dimensions, quantization, full_precision
+			FROM embedding_models
+		`,
+	)
+	if err != nil {
+		return fmt.Errorf("querying embedding models: %w", err)
+	}
+
+	defer rows.Close()
+
+	models := make(map[Model]modelMetadata)
+
+	for rows.Next() {
+		var (
+			model    Model
+			metadata modelMetadata
+		)
+
+		if err := rows.Scan(
+			&model,
+			&metadata.dimensions,
+			&metadata.quantization,
+			&metadata.fullPrecision,
+		); err != nil {
+			return fmt.Errorf("scanning embedding model: %w", err)
+		}
+
+		if !model.known() {
+			return fmt.Errorf("model %d in the database isn't supported by this build", int(model))
+		}
+
+		if model.Dimensions() != metadata.dimensions {
+			return fmt.Errorf(
+				"model %s has %d dimensions in the database, but %d in this build",
+				model,
+				metadata.dimensions,
+				model.Dimensions(),
+			)
+		}
+
+		models[model] = metadata
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating embedding models: %w", err)
+	}
+
+	s.models = models
+
+	return nil
+}
+
+// RegisterModels records the truncated variants among the models in the
+// `embedding_models` table, if they aren't already, so that their embeddings
+// can be stored. Base models are always recorded.
+func (s *SQLiteVec) RegisterModels(ctx context.Context, models []Model) (err error) {
+	ctx, span := startSQLiteSpan(ctx, "RegisterModels")
+	defer func() { endSpan(span, err) }()
+
+	var unregistered []Model
+
+	for _, model := range models {
+		if _, ok := s.models[model]; !ok && model.IsVariant() {
+			unregistered = append(unregistered, model)
+		}
+	}
+
+	if len(unregistered) == 0 {
+		return nil
+	}
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, model := range unregistered {
+			if _, err := tx.ExecContext(
+				ctx,
+				`
+					INSERT INTO embedding_models (id, name, dimensions)
+					VALUES (?, ?, ?)
+					ON CONFLICT(id) DO NOTHING
+				`,
+				model,
+				model.String(),
+				model.Dimensions(),
+			); err != nil {
+				return fmt.Errorf("registering model %s: %w", model, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Another process may have registered (and configured) the models in the
+	// meantime.
+	return s.loadModelMetadata(ctx)
+}
+
+// ModelDimensions returns the number of dimensions expected for embeddings of
+// the model.
+func (s *SQLiteVec) ModelDimensions(model Model) (int, error) {
+	metadata, ok := s.models[model]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownModel, model)
+	}
+
+	return metadata.dimensions, nil
+}
+
+// KnownModels returns all the models recorded in the database, in ID order.
+func (s *SQLiteVec) KnownModels() []Model {
+	models := make([]Model, 0, len(s.models))
+
+	for model := range s.models {
+		models = append(models, model)
+	}
+
+	slices.Sort(models)
+
+	return models
+}
+
+// checkDimensions returns a [DimensionMismatchError] if the embedding does not
+// have the expected number of dimensions for the model.
+func (s *SQLiteVec) checkDimensions(model Model, embedding Embedding) error {
+	expected, err := s.ModelDimensions(model)
+	if err != nil {
+		return err
+	}
+
+	if len(embedding) != expected {
+		return &DimensionMismatchError{
+			Model:    model,
+			Expected: expected,
+			Actual:   len(embedding),
+		}
+	}
+
+	return nil
+}
+
+// DeleteModelEmbeddings deletes all of the model's embeddings, e.g. to reclaim
+// space from a model which is no longer used.
+func (s *SQLiteVec) DeleteModelEmbeddings(ctx context.Context, model Model) (_ int64, err error) {
+	ctx, span := startSQLiteSpan(ctx, "DeleteModelEmbeddings", attribute.String("model", model.String()))
+	defer func() { endSpan(span, err) }()
+
+	var deleted int64
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(
+			ctx,
+			`
+				DELETE FROM quantized_embeddings
+				WHERE embedding_model_id = ?
+			`,
+			model,
+		); err != nil {
+			return fmt.Errorf("deleting quantized embeddings: %w", err)
+		}
+
+		result, err := tx.ExecContext(
+			ctx,
+			`
+				DELETE FROM embeddings
+				WHERE embedding_model_id = ?
+			`,
+			model,
+		)
+		if err != nil {
+			return fmt.Errorf("deleting embeddings: %w", err)
+		}
+
+		deleted, err = result.RowsAffected()
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// DeriveVariantEmbeddings creates the embeddings of a truncated variant from
+// the stored embeddings of its base model, for every feature that doesn't
+// have one yet. This avoids re-embedding the whole corpus to try out a
+// variant.
+//
+// Returns the number of embeddings created.
+func (s *SQLiteVec) DeriveVariantEmbeddings(ctx context.Context, variant Model) (_ int64, err error) {
+	ctx, span := startSQLiteSpan(ctx, "DeriveVariantEmbeddings", attribute.String("model", variant.String()))
+	defer func() { endSpan(span, err) }()
+
+	if !variant.IsVariant() {
+		return 0, fmt.Errorf("model %s is not a variant", variant)
+	}
+
+	for _, model := range []Model{variant.Base(), variant} {
+		if !s.KeepsFullPrecision(model) {
+			return 0, fmt.Errorf("%w: %s", ErrNoFullPrecision, model)
+		}
+	}
+
+	quantization, err := s.ModelQuantization(variant)
+	if err != nil {
+		return 0, err
+	}
+
+	var derived int64
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(
+			ctx,
+			`
+				INSERT INTO embeddings (
+					word_feature_id,
+					embedding_model_id,
+					embedding
+				)
+				SELECT
+					base.word_feature_id,
+					?,
+					vec_normalize(vec_slice(base.embedding, 0, ?))
+				FROM embeddings base
+				WHERE base.embedding_model_id = ?
+				ON CONFLICT(word_feature_id, embedding_model_id) DO NOTHING
+			`,
+			variant,
+			variant.Dimensions(),
+			variant.Base(),
+		)
+		if err != nil {
+			return fmt.Errorf("deriving embeddings: %w", err)
+		}
+
+		derived, err = result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if quantization == QuantizationNone {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(
+			ctx,
+			fmt.Sprintf(
+				`
+					INSERT INTO quantized_embeddings (
+						word_feature_id,
+						embedding_model_id,
+						embedding
+					)
+					SELECT word_feature_id, embedding_model_id, %s
+					FROM embeddings
+					WHERE embedding_model_id = ?
+					ON CONFLICT(word_feature_id, embedding_model_id) DO NOTHING
+				`,
+				quantization.quantize("embedding"),
+			),
+			variant,
+		); err != nil {
+			return fmt.Errorf("quantizing derived embeddings: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return derived, nil
+}
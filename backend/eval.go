@@ -0,0 +1,255 @@
+package backend
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+// EvalQuery is a labelled query for retrieval evaluation: a description, and
+// the words it should find.
+type EvalQuery struct {
+	Query string   `json:"query"`
+	Words []string `json:"words"`
+}
+
+// ReadEvalQueries reads labelled queries from JSON lines, e.g.
+//
+//	{"query": "a very small amount", "words": ["smidge", "tad"]}
+//
+// Blank lines and lines starting with `#` are ignored.
+func ReadEvalQueries(r io.Reader) ([]EvalQuery, error) {
+	var queries []EvalQuery
+
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var query EvalQuery
+
+		if err := json.Unmarshal([]byte(text), &query); err != nil {
+			return nil, fmt.Errorf("parsing line %d: %w", line, err)
+		}
+
+		if query.Query == "" || len(query.Words) == 0 {
+			return nil, fmt.Errorf("line %d: a query and at least one word are required", line)
+		}
+
+		queries = append(queries, query)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading queries: %w", err)
+	}
+
+	return queries, nil
+}
+
+// AddEvalQuery stores a generated query for the word. Returns false if the
+// query was already stored for the word.
+func (s *SQLiteVec) AddEvalQuery(ctx context.Context, query string, wordID int64) (_ bool, err error) {
+	ctx, span := startSQLiteSpan(ctx, "AddEvalQuery")
+	defer func() { endSpan(span, err) }()
+
+	result, err := s.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO eval_queries (query, word_id)
+			VALUES (?, ?)
+			ON CONFLICT(query, word_id) DO NOTHING
+		`,
+		query,
+		wordID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("inserting eval query: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("checking inserted eval query: %w", err)
+	}
+
+	return inserted > 0, nil
+}
+
+// GetEvalQueries returns the stored queries, labelled with their words.
+func (s *SQLiteVec) GetEvalQueries(ctx context.Context) (_ []EvalQuery, err error) {
+	ctx, span := startSQLiteSpan(ctx, "GetEvalQueries")
+	defer func() { endSpan(span, err) }()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+			SELECT q.query, w.word
+			FROM eval_queries q
+			JOIN words w ON w.id = q.word_id
+			ORDER BY q.id
+		`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying eval queries: %w", err)
+	}
+
+	defer rows.Close()
+
+	var queries []EvalQuery
+
+	for rows.Next() {
+		var query, word string
+
+		if err := rows.Scan(&query, &word); err != nil {
+			return nil, fmt.Errorf("scanning eval query: %w", err)
+		}
+
+		queries = append(queries, EvalQuery{
+			Query: query,
+			Words: []string{word},
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating eval queries: %w", err)
+	}
+
+	return queries, nil
+}
+
+// RetrievalMetrics summarises how well a model retrieved the expected words
+// over a set of queries.
+type RetrievalMetrics struct {
+	Model   Model      `json:"model"`
+	Mode    SearchMode `json:"mode"`
+	Queries int        `json:"queries"`
+	// Recall is the mean fraction of the expected words found in the top k.
+	Recall float64 `json:"recall"`
+	// MRR is the mean reciprocal rank of the first expected word found in the
+	// top k.
+	MRR float64 `json:"mrr"`
+	// NDCG is the mean normalised discounted cumulative gain of the top k,
+	// with binary relevance.
+	NDCG float64 `json:"ndcg"`
+}
+
+// Evaluate runs each query through [Searcher.Search] in each of the modes, and
+// scores the top `k` results of each model against the expected words.
+//
+// Each query is searched once per mode, so the embedders should be cached to
+// avoid embedding each query repeatedly.
+func (s *Searcher) Evaluate(
+	ctx context.Context,
+	queries []EvalQuery,
+	k int,
+	modes []SearchMode,
+) ([]RetrievalMetrics, error) {
+	type key struct {
+		model Model
+		mode  SearchMode
+	}
+
+	totals := make(map[key]*RetrievalMetrics)
+
+	for _, mode := range modes {
+		for _, query := range queries {
+			results, err := s.Search(ctx, query.Query, k, mode, false)
+			if err != nil {
+				return nil, fmt.Errorf("searching for %q: %w", query.Query, err)
+			}
+
+			for model, definitions := range results {
+				total, ok := totals[key{model, mode}]
+				if !ok {
+					total = &RetrievalMetrics{
+						Model: model,
+						Mode:  mode,
+					}
+					totals[key{model, mode}] = total
+				}
+
+				recall, reciprocalRank, ndcg := scoreResults(definitions, query.Words, k)
+
+				total.Queries++
+				total.Recall += recall
+				total.MRR += reciprocalRank
+				total.NDCG += ndcg
+			}
+		}
+	}
+
+	metrics := make([]RetrievalMetrics, 0, len(totals))
+
+	for _, total := range totals {
+		n := float64(total.Queries)
+
+		total.Recall /= n
+		total.MRR /= n
+		total.NDCG /= n
+
+		metrics = append(metrics, *total)
+	}
+
+	slices.SortFunc(metrics, func(a, b RetrievalMetrics) int {
+		return cmp.Or(
+			cmp.Compare(a.Model, b.Model),
+			slices.Index(SearchModes, a.Mode)-slices.Index(SearchModes, b.Mode),
+		)
+	})
+
+	return metrics, nil
+}
+
+// scoreResults returns the recall, reciprocal rank and nDCG of the top `k`
+// results against the expected words.
+//
+// Words are matched case-insensitively, and only the first result for each
+// word counts, as the same word may have several definitions.
+func scoreResults(results []SimilarDefinition, expected []string, k int) (recall, reciprocalRank, ndcg float64) {
+	relevant := make(map[string]bool, len(expected))
+
+	for _, word := range expected {
+		relevant[strings.ToLower(word)] = true
+	}
+
+	var (
+		found = make(map[string]bool, len(expected))
+		dcg   float64
+	)
+
+	for i, result := range results[:min(k, len(results))] {
+		word := strings.ToLower(result.Word.Word)
+
+		if !relevant[word] || found[word] {
+			continue
+		}
+
+		found[word] = true
+
+		if reciprocalRank == 0 {
+			reciprocalRank = 1 / float64(i+1)
+		}
+
+		dcg += 1 / math.Log2(float64(i+2))
+	}
+
+	var idealDCG float64
+
+	for i := range min(k, len(relevant)) {
+		idealDCG += 1 / math.Log2(float64(i+2))
+	}
+
+	recall = float64(len(found)) / float64(len(relevant))
+	ndcg = dcg / idealDCG
+
+	return recall, reciprocalRank, ndcg
+}
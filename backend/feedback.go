@@ -0,0 +1,201 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Verdict is a user's judgement of a search result.
+type Verdict string
+
+const (
+	// VerdictRelevant is a result which fits the query.
+	VerdictRelevant Verdict = "relevant"
+	// VerdictIrrelevant is a result which doesn't fit the query.
+	VerdictIrrelevant Verdict = "irrelevant"
+	// VerdictMeant is the word the user was looking for.
+	VerdictMeant Verdict = "meant"
+)
+
+// Verdicts lists all the verdicts.
+var Verdicts = []Verdict{
+	VerdictRelevant,
+	VerdictIrrelevant,
+	VerdictMeant,
+}
+
+// VerdictFromString parses a verdict name.
+func VerdictFromString(s string) (Verdict, error) {
+	verdict := Verdict(s)
+
+	if !slices.Contains(Verdicts, verdict) {
+		return "", fmt.Errorf("unknown verdict: %s", s)
+	}
+
+	return verdict, nil
+}
+
+// Feedback is a user's verdict on one result of a search.
+type Feedback struct {
+	Query  string `json:"query"`
+	Model  Model  `json:"model"`
+	WordID int64  `json:"word_id"`
+	// Rank is the 1-based position of the result in the model's results.
+	Rank    int     `json:"rank"`
+	Verdict Verdict `json:"verdict"`
+}
+
+// AddFeedback stores a verdict on a search result. Returns [ErrWordNotFound]
+// if the word doesn't exist.
+func (s *SQLiteVec) AddFeedback(ctx context.Context, feedback Feedback) (err error) {
+	ctx, span := startSQLiteSpan(
+		ctx,
+		"AddFeedback",
+		attribute.String("model", feedback.Model.String()),
+		attribute.Int64("word_id", feedback.WordID),
+		attribute.Int("rank", feedback.Rank),
+		attribute.String("verdict", string(feedback.Verdict)),
+	)
+	defer func() { endSpan(span, err) }()
+
+	result, err := s.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO feedback (query, embedding_model_id, word_id, rank, verdict)
+			SELECT ?, ?, id, ?, ?
+			FROM words
+			WHERE id = ?
+		`,
+		feedback.Query,
+		feedback.Model,
+		feedback.Rank,
+		feedback.Verdict,
+		feedback.WordID,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting feedback: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking inserted feedback: %w", err)
+	}
+
+	if inserted == 0 {
+		return ErrWordNotFound
+	}
+
+	return nil
+}
+
+// GetFeedback returns all the feedback given, oldest first.
+func (s *SQLiteVec) GetFeedback(ctx context.Context) (_ []Feedback, err error) {
+	ctx, span := startSQLiteSpan(ctx, "GetFeedback")
+	defer func() { endSpan(span, err) }()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+			SELECT query, embedding_model_id, word_id, rank, verdict
+			FROM feedback
+			ORDER BY id
+		`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying feedback: %w", err)
+	}
+
+	defer rows.Close()
+
+	var feedback []Feedback
+
+	for rows.Next() {
+		var f Feedback
+
+		if err := rows.Scan(&f.Query, &f.Model, &f.WordID, &f.Rank, &f.Verdict); err != nil {
+			return nil, fmt.Errorf("scanning feedback: %w", err)
+		}
+
+		feedback = append(feedback, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating feedback: %w", err)
+	}
+
+	return feedback, nil
+}
+
+// FeedbackMetrics summarises the user feedback on a model's results.
+type FeedbackMetrics struct {
+	Model Model `json:"model"`
+	// Verdicts is the number of results given feedback.
+	Verdicts   int `json:"verdicts"`
+	Relevant   int `json:"relevant"`
+	Irrelevant int `json:"irrelevant"`
+	Meant      int `json:"meant"`
+	// Precision is the fraction of the results given feedback which were
+	// relevant, or the word meant.
+	Precision float64 `json:"precision"`
+	// MeantMRR is the mean reciprocal rank of the results marked as the word
+	// meant, or zero if there are none.
+	MeantMRR float64 `json:"meant_mrr"`
+}
+
+// GetFeedbackMetrics summarises the user feedback for each model, in model
+// order.
+func (s *SQLiteVec) GetFeedbackMetrics(ctx context.Context) (_ []FeedbackMetrics, err error) {
+	ctx, span := startSQLiteSpan(ctx, "GetFeedbackMetrics")
+	defer func() { endSpan(span, err) }()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+			SELECT
+				embedding_model_id,
+				COUNT(*),
+				COUNT(*) FILTER (WHERE verdict = 'relevant'),
+				COUNT(*) FILTER (WHERE verdict = 'irrelevant'),
+				COUNT(*) FILTER (WHERE verdict = 'meant'),
+				COALESCE(AVG(1.0 / rank) FILTER (WHERE verdict = 'meant'), 0)
+			FROM feedback
+			GROUP BY embedding_model_id
+			ORDER BY embedding_model_id
+		`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying feedback: %w", err)
+	}
+
+	defer rows.Close()
+
+	var metrics []FeedbackMetrics
+
+	for rows.Next() {
+		var m FeedbackMetrics
+
+		if err := rows.Scan(
+			&m.Model,
+			&m.Verdicts,
+			&m.Relevant,
+			&m.Irrelevant,
+			&m.Meant,
+			&m.MeantMRR,
+		); err != nil {
+			return nil, fmt.Errorf("scanning feedback: %w", err)
+		}
+
+		m.Precision = float64(m.Relevant+m.Meant) / float64(m.Verdicts)
+
+		metrics = append(metrics, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating feedback: %w", err)
+	}
+
+	return metrics, nil
+}
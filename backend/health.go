@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// readinessTimeout bounds how long the readiness checks may take in total.
+const readinessTimeout = 5 * time.Second
+
+// HealthResponse is the body of the health and readiness endpoints.
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+const (
+	healthStatusOK    = "ok"
+	healthStatusError = "error"
+)
+
+// CheckReady verifies that the database is reachable, has the sqlite-vec
+// extension loaded, and is at the expected schema version.
+func (s *SQLiteVec) CheckReady(ctx context.Context) (err error) {
+	ctx, span := startSQLiteSpan(ctx, "CheckReady")
+	defer func() { endSpan(span, err) }()
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("pinging database: %w", err)
+	}
+
+	var vecVersion string
+
+	if err := s.db.QueryRowContext(ctx, "SELECT vec_version()").Scan(&vecVersion); err != nil {
+		return fmt.Errorf("checking sqlite-vec extension: %w", err)
+	}
+
+	version, err := schemaVersion(ctx, s.db)
+	if err != nil {
+		return err
+	}
+
+	if version != SchemaVersion {
+		return fmt.Errorf(
+			"database schema version %d does not match expected version %d",
+			version,
+			SchemaVersion,
+		)
+	}
+
+	return nil
+}
+
+// Healthz reports that the process is alive. It has no dependencies, so
+// always succeeds.
+func (a *API) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, r, http.StatusOK, HealthResponse{
+		Status: healthStatusOK,
+	})
+}
+
+// Readyz reports whether the API can serve searches: the database must be
+// usable, and every configured embedder reachable.
+func (a *API) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	response := HealthResponse{
+		Status: healthStatusOK,
+		Checks: make(map[string]string),
+	}
+
+	check := func(name string, err error) {
+		if err != nil {
+			slog.WarnContext(
+				ctx,
+				"readiness check failed",
+				slog.String("check", name),
+				slog.Any("error", err),
+			)
+
+			response.Status = healthStatusError
+			response.Checks[name] = err.Error()
+
+			return
+		}
+
+		response.Checks[name] = healthStatusOK
+	}
+
+	check("database", a.sqliteVec.CheckReady(ctx))
+
+	for model, err := range a.embedder.Probe(ctx) {
+		check(model.String(), err)
+	}
+
+	status := http.StatusOK
+	if response.Status != healthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeHealth(w, r, status, response)
+}
+
+func writeHealth(
+	w http.ResponseWriter,
+	r *http.Request,
+	status int,
+	response HealthResponse,
+) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(
+			r.Context(),
+			"writing health response",
+			slog.Any("error", err),
+		)
+	}
+}
@@ -0,0 +1,211 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// metricsNamespace prefixes all the metrics exported by the backend.
+const metricsNamespace = "revdict"
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests, by route.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	embeddingDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "embedding_duration_seconds",
+			Help:      "Duration of embedding requests, by model.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+		},
+		[]string{"model"},
+	)
+
+	embeddingErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "embedding_errors_total",
+			Help:      "Number of failed embedding requests, by model.",
+		},
+		[]string{"model"},
+	)
+
+	embeddingCacheRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "embedding_cache_requests_total",
+			Help:      "Number of embedding cache lookups, by model and result (hit or miss).",
+		},
+		[]string{"model", "result"},
+	)
+
+	relatedWordsDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "sqlite_related_words_duration_seconds",
+			Help:      "Duration of SQLite similarity queries, by model.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+		},
+		[]string{"model"},
+	)
+
+	openaiTokens = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "openai_tokens_total",
+			Help:      "Number of tokens used by OpenAI requests, by model and kind (prompt or total).",
+		},
+		[]string{"model", "kind"},
+	)
+)
+
+// MetricsMiddleware records the duration of each request, labelled with the
+// chi route pattern that served it.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		// Use the route pattern rather than the path, to keep the label
+		// cardinality bounded.
+		route := "unmatched"
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				route = pattern
+			}
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		httpRequestDuration.WithLabelValues(
+			r.Method,
+			route,
+			strconv.Itoa(status),
+		).Observe(time.Since(start).Seconds())
+	})
+}
+
+// dbCollector reports the number of rows in each table of the database when
+// scraped.
+type dbCollector struct {
+	sqliteVec *SQLiteVec
+	rows      *prometheus.Desc
+}
+
+var _ prometheus.Collector = &dbCollector{}
+
+// NewDBCollector returns a collector that exports the row counts of every
+// table in the given database.
+func NewDBCollector(sqliteVec *SQLiteVec) prometheus.Collector {
+	return &dbCollector{
+		sqliteVec: sqliteVec,
+		rows: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "db", "rows"),
+			"Number of rows in each database table.",
+			[]string{"table"},
+			nil,
+		),
+	}
+}
+
+// Describe implements [prometheus.Collector].
+func (c *dbCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.rows
+}
+
+// Collect implements [prometheus.Collector].
+func (c *dbCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	counts, err := c.sqliteVec.TableRowCounts(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "collecting table row counts", slog.Any("error", err))
+		ch <- prometheus.NewInvalidMetric(c.rows, err)
+
+		return
+	}
+
+	for table, count := range counts {
+		ch <- prometheus.MustNewConstMetric(
+			c.rows,
+			prometheus.GaugeValue,
+			float64(count),
+			table,
+		)
+	}
+}
+
+// TableRowCounts returns the number of rows in each table of the database.
+func (s *SQLiteVec) TableRowCounts(ctx context.Context) (map[string]int64, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+			SELECT name
+			FROM sqlite_master
+			WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+		`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying tables: %w", err)
+	}
+
+	defer rows.Close()
+
+	var tables []string
+
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, fmt.Errorf("scanning table name: %w", err)
+		}
+
+		tables = append(tables, table)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating tables: %w", err)
+	}
+
+	counts := make(map[string]int64, len(tables))
+
+	for _, table := range tables {
+		var count int64
+
+		// Table names can't be bound as parameters, but these come straight
+		// from sqlite_master.
+		if err := s.db.QueryRowContext(
+			ctx,
+			fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, table),
+		).Scan(&count); err != nil {
+			return nil, fmt.Errorf("counting rows in %s: %w", table, err)
+		}
+
+		counts[table] = count
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,259 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+)
+
+// schema is the full, current database schema. It is applied as-is to fresh
+// databases.
+//
+//go:embed schema.sql
+var schema string
+
+// migrations upgrade an existing database to the current schema.
+//
+// The schema version is tracked using SQLite's `user_version` pragma, and is
+// the number of migrations that have been applied. A database created from
+// [schema] starts at the latest version. Migrations must only ever be appended
+// to, and any change to a migration must also be reflected in schema.sql.
+var migrations = []string{
+	// 1: API keys and their usage.
+	`
+		CREATE TABLE api_keys (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			key_hash BLOB NOT NULL,
+			scopes TEXT NOT NULL,
+			created_at INTEGER NOT NULL DEFAULT (unixepoch()),
+			revoked_at INTEGER,
+			UNIQUE (key_hash)
+		) STRICT;
+
+		CREATE TABLE api_key_usage (
+			api_key_id INTEGER NOT NULL,
+			day TEXT NOT NULL,
+			requests INTEGER NOT NULL DEFAULT 0,
+			last_used_at INTEGER NOT NULL,
+			PRIMARY KEY (api_key_id, day),
+			FOREIGN KEY (api_key_id) REFERENCES api_keys (id)
+		) STRICT;
+	`,
+	// 2: Expected embedding dimensions for each model.
+	`
+		ALTER TABLE embedding_models ADD COLUMN dimensions INTEGER NOT NULL DEFAULT 0;
+
+		UPDATE embedding_models SET dimensions = 4096 WHERE id = 1;
+		UPDATE embedding_models SET dimensions = 512 WHERE id = 2;
+		UPDATE embedding_models SET dimensions = 3072 WHERE id = 3;
+
+		CREATE TRIGGER embeddings_dimensions_insert BEFORE INSERT ON embeddings
+		WHEN vec_length(NEW.embedding) != (
+			SELECT dimensions FROM embedding_models WHERE id = NEW.embedding_model_id
+		)
+		BEGIN
+			SELECT RAISE(ABORT, 'embedding dimensions do not match the model');
+		END;
+
+		CREATE TRIGGER embeddings_dimensions_update BEFORE UPDATE OF embedding ON embeddings
+		WHEN vec_length(NEW.embedding) != (
+			SELECT dimensions FROM embedding_models WHERE id = NEW.embedding_model_id
+		)
+		BEGIN
+			SELECT RAISE(ABORT, 'embedding dimensions do not match the model');
+		END;
+	`,
+	// 3: Quantized embeddings for the first stage of searches, and whether the
+	// full-precision embeddings are kept alongside them.
+	`
+		ALTER TABLE embedding_models ADD COLUMN quantization TEXT NOT NULL DEFAULT 'none'
+			CHECK (quantization IN ('none', 'int8', 'bit'));
+
+		ALTER TABLE embedding_models ADD COLUMN full_precision INTEGER NOT NULL DEFAULT 1
+			CHECK (full_precision IN (0, 1));
+
+		CREATE TABLE quantized_embeddings (
+			word_feature_id INTEGER NOT NULL,
+			embedding_model_id INTEGER NOT NULL,
+			embedding BLOB NOT NULL,
+			PRIMARY KEY (word_feature_id, embedding_model_id),
+			FOREIGN KEY (word_feature_id) REFERENCES word_features (id),
+			FOREIGN KEY (embedding_model_id) REFERENCES embedding_models (id)
+		) STRICT;
+	`,
+	// 4: Generated queries for retrieval evaluation.
+	`
+		CREATE TABLE eval_queries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			query TEXT NOT NULL,
+			word_id INTEGER NOT NULL,
+			created_at INTEGER NOT NULL DEFAULT (unixepoch()),
+			UNIQUE (query, word_id),
+			FOREIGN KEY (word_id) REFERENCES words (id)
+		) STRICT;
+	`,
+	// 5: Case-insensitive index for word prefix suggestions.
+	`
+		CREATE INDEX words_word_nocase ON words (word COLLATE NOCASE);
+	`,
+	// 6: Content ratings of words, for safe searches. Existing words are
+	// unrated, so safe searches find nothing until `revdict reingest` has rated
+	// them.
+	`
+		ALTER TABLE words ADD COLUMN content_rating TEXT NOT NULL DEFAULT 'unrated'
+			CHECK (content_rating IN ('unrated', 'safe', 'explicit', 'offensive'));
+	`,
+	// 7: User feedback on search results.
+	`
+		CREATE TABLE feedback (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			query TEXT NOT NULL,
+			embedding_model_id INTEGER NOT NULL,
+			word_id INTEGER NOT NULL,
+			rank INTEGER NOT NULL CHECK (rank >= 1),
+			verdict TEXT NOT NULL CHECK (verdict IN ('relevant', 'irrelevant', 'meant')),
+			created_at INTEGER NOT NULL DEFAULT (unixepoch()),
+			FOREIGN KEY (embedding_model_id) REFERENCES embedding_models (id),
+			FOREIGN KEY (word_id) REFERENCES words (id)
+		) STRICT;
+	`,
+	// 8: Rankers learnt from user feedback.
+	`
+		CREATE TABLE rankers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			bias REAL NOT NULL,
+			samples INTEGER NOT NULL,
+			auc REAL NOT NULL,
+			baseline_auc REAL NOT NULL,
+			active INTEGER NOT NULL DEFAULT 0 CHECK (active IN (0, 1)),
+			created_at INTEGER NOT NULL DEFAULT (unixepoch())
+		) STRICT;
+
+		CREATE UNIQUE INDEX rankers_active ON rankers (active) WHERE active = 1;
+
+		CREATE TABLE ranker_models (
+			ranker_id INTEGER NOT NULL,
+			embedding_model_id INTEGER NOT NULL,
+			intercept REAL NOT NULL,
+			slope REAL NOT NULL,
+			weight REAL NOT NULL,
+			PRIMARY KEY (ranker_id, embedding_model_id),
+			FOREIGN KEY (ranker_id) REFERENCES rankers (id),
+			FOREIGN KEY (embedding_model_id) REFERENCES embedding_models (id)
+		) STRICT;
+	`,
+	// 9: Opt-in log of searches, for analytics.
+	`
+		CREATE TABLE query_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			query TEXT,
+			query_hash BLOB NOT NULL,
+			safe INTEGER NOT NULL,
+			zero_results INTEGER NOT NULL,
+			created_at INTEGER NOT NULL DEFAULT (unixepoch())
+		) STRICT;
+
+		CREATE INDEX query_log_created_at ON query_log (created_at);
+
+		CREATE TABLE query_log_models (
+			query_log_id INTEGER NOT NULL,
+			embedding_model_id INTEGER NOT NULL,
+			results INTEGER NOT NULL,
+			latency_ms REAL NOT NULL,
+			top_word_id INTEGER,
+			PRIMARY KEY (query_log_id, embedding_model_id),
+			FOREIGN KEY (query_log_id) REFERENCES query_log (id),
+			FOREIGN KEY (embedding_model_id) REFERENCES embedding_models (id),
+			FOREIGN KEY (top_word_id) REFERENCES words (id)
+		) STRICT;
+	`,
+}
+
+// SchemaVersion is the schema version expected by this build.
+var SchemaVersion = len(migrations)
+
+// migrate brings the database schema up to [SchemaVersion].
+func (s *SQLiteVec) migrate(ctx context.Context) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var table string
+
+	if err := tx.QueryRowContext(
+		ctx,
+		`
+			SELECT name
+			FROM sqlite_master
+			WHERE type = 'table' AND name = 'words'
+		`,
+	).Scan(&table); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("querying existing tables: %w", err)
+	}
+
+	// A fresh database gets the full schema in one go.
+	if table == "" {
+		if _, err := tx.ExecContext(ctx, schema); err != nil {
+			return fmt.Errorf("applying schema: %w", err)
+		}
+	} else {
+		version, err := schemaVersion(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		if version > SchemaVersion {
+			return fmt.Errorf(
+				"database schema version %d is newer than supported version %d",
+				version,
+				SchemaVersion,
+			)
+		}
+
+		for i, migration := range migrations[version:] {
+			if _, err := tx.ExecContext(ctx, migration); err != nil {
+				return fmt.Errorf("applying migration %d: %w", version+i+1, err)
+			}
+		}
+	}
+
+	// PRAGMA statements can't take bound parameters.
+	if _, err := tx.ExecContext(
+		ctx,
+		fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion),
+	); err != nil {
+		return fmt.Errorf("setting schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// queryRower is implemented by both [sql.DB] and [sql.Tx].
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// schemaVersion returns the schema version recorded in the database.
+func schemaVersion(ctx context.Context, q queryRower) (int, error) {
+	var version int
+
+	if err := q.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("querying schema version: %w", err)
+	}
+
+	return version, nil
+}
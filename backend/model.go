@@ -1,6 +1,10 @@
 package backend
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 type Model int
 
@@ -10,20 +14,140 @@ const (
 	ModelOpenAITextEmbedding3Large  Model = 3
 )
 
+// MinVariantDimensions is the fewest dimensions a truncated variant may have.
+const MinVariantDimensions = 256
+
+// variantShift is the number of low bits of a truncated variant's ID which
+// hold the ID of its base model. The remaining bits hold its dimensions, so
+// that each variant has a stable ID without being listed anywhere.
+const variantShift = 8
+
+// modelInfo describes a base [Model]. The IDs, names and dimensions must match
+// the `embedding_models` table.
+type modelInfo struct {
+	name string
+	// displayName is the human-friendly name shown to users.
+	displayName string
+	dimensions  int
+	// truncatable is whether the model's embeddings can be truncated to their
+	// leading dimensions (Matryoshka representation learning), so that it can
+	// have variants.
+	truncatable bool
+}
+
+var modelInfos = map[Model]modelInfo{
+	ModelQwen3Embedding8B4B_DWQ: {
+		name:        "mlx-community/Qwen3-Embedding-8B-4bit-DWQ",
+		displayName: "Qwen3 Embedding 8B",
+		dimensions:  4096,
+		truncatable: true,
+	},
+	ModelAppleNLContextualEmbedding: {
+		name:        "apple/nlcontextualembedding",
+		displayName: "Apple NL Contextual Embedding",
+		dimensions:  512,
+	},
+	ModelOpenAITextEmbedding3Large: {
+		name:        "openai/text-embedding-3-large",
+		displayName: "OpenAI Embedding 3 Large",
+		dimensions:  3072,
+		truncatable: true,
+	},
+}
+
+// Variant returns the truncated (Matryoshka) variant of the base model with
+// the given number of dimensions, whose embeddings are the leading dimensions
+// of the base model's embeddings, renormalised.
+//
+// Variants are named `<base>@<dimensions>`, e.g.
+// `openai/text-embedding-3-large@256`.
+func Variant(base Model, dimensions int) (Model, error) {
+	info, ok := modelInfos[base]
+	if !ok {
+		return 0, fmt.Errorf("unknown model: %d", base)
+	}
+
+	if !info.truncatable {
+		return 0, fmt.Errorf("model %s doesn't support truncated variants", info.name)
+	}
+
+	if dimensions < MinVariantDimensions || dimensions >= info.dimensions {
+		return 0, fmt.Errorf(
+			"variants of %s must have between %d and %d dimensions",
+			info.name,
+			MinVariantDimensions,
+			info.dimensions-1,
+		)
+	}
+
+	return Model(dimensions<<variantShift | int(base)), nil
+}
+
+// String returns the model's name, or its ID if it isn't supported by this
+// build, so that unknown models can still be reported in errors.
 func (m Model) String() string {
-	switch m {
-	case ModelQwen3Embedding8B4B_DWQ:
-		return "mlx-community/Qwen3-Embedding-8B-4bit-DWQ"
-	case ModelAppleNLContextualEmbedding:
-		return "apple/nlcontextualembedding"
-	case ModelOpenAITextEmbedding3Large:
-		return "openai/text-embedding-3-large"
+	if !m.known() {
+		return fmt.Sprintf("Model(%d)", int(m))
+	}
+
+	if m.IsVariant() {
+		return fmt.Sprintf("%s@%d", m.Base(), m.Dimensions())
 	}
 
-	panic("unknown model")
+	return modelInfos[m].name
+}
+
+// DisplayName returns the human-friendly name of the model.
+func (m Model) DisplayName() string {
+	if !m.known() {
+		return m.String()
+	}
+
+	if m.IsVariant() {
+		return fmt.Sprintf("%s (%dd)", m.Base().DisplayName(), m.Dimensions())
+	}
+
+	return modelInfos[m].displayName
+}
+
+// Base returns the model which produces this model's embeddings: either the
+// model itself, or the full-size model of a truncated variant.
+func (m Model) Base() Model {
+	return m & (1<<variantShift - 1)
+}
+
+// Dimensions returns the number of dimensions of the model's embeddings.
+func (m Model) Dimensions() int {
+	if m.IsVariant() {
+		return int(m >> variantShift)
+	}
+
+	return modelInfos[m].dimensions
+}
+
+// IsVariant reports whether the model is a truncated variant of another model.
+func (m Model) IsVariant() bool {
+	return m>>variantShift != 0
+}
+
+// known reports whether the model is supported by this build.
+func (m Model) known() bool {
+	if !m.IsVariant() {
+		_, ok := modelInfos[m]
+
+		return ok
+	}
+
+	variant, err := Variant(m.Base(), m.Dimensions())
+
+	return err == nil && variant == m
 }
 
 func (m Model) MarshalText() ([]byte, error) {
+	if !m.known() {
+		return nil, fmt.Errorf("unknown model: %d", int(m))
+	}
+
 	return []byte(m.String()), nil
 }
 
@@ -38,19 +162,34 @@ func (m *Model) UnmarshalText(data []byte) error {
 	return nil
 }
 
+// ModelFromString parses a model name, including the `@<dimensions>` suffix
+// of a truncated variant.
 func ModelFromString(s string) (Model, error) {
-	switch s {
-	case "mlx-community/Qwen3-Embedding-8B-4bit-DWQ":
-		return ModelQwen3Embedding8B4B_DWQ, nil
-	case "apple/nlcontextualembedding":
-		return ModelAppleNLContextualEmbedding, nil
-	case "openai/text-embedding-3-large":
-		return ModelOpenAITextEmbedding3Large, nil
+	name, dimensions, isVariant := strings.Cut(s, "@")
+
+	for model, info := range modelInfos {
+		// Names are matched case-insensitively, as viper lowercases the keys of
+		// maps in the config, such as the distance thresholds.
+		if !strings.EqualFold(info.name, name) {
+			continue
+		}
+
+		if !isVariant {
+			return model, nil
+		}
+
+		n, err := strconv.Atoi(dimensions)
+		if err != nil {
+			return 0, fmt.Errorf("parsing dimensions of model %s: %w", s, err)
+		}
+
+		return Variant(model, n)
 	}
 
 	return 0, fmt.Errorf("unknown model: %s", s)
 }
 
+// Models are the models enabled by default.
 var Models = []Model{
 	ModelQwen3Embedding8B4B_DWQ,
 	//.TODO: re-enable when the Apple NL Contextual Embedding is deployed.
@@ -0,0 +1,325 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Quantization is how a model's embeddings are quantized for the first stage
+// of a search. The full-precision embeddings are kept alongside the quantized
+// ones by default, and used to rescore the candidates found using the
+// quantized embeddings, so quantizing alone makes the database larger. To save
+// space, the full-precision embeddings can be dropped (see
+// [SQLiteVec.DropFullPrecision]), at the cost of ranking on the quantized
+// distances alone.
+type Quantization string
+
+const (
+	// QuantizationNone searches the full-precision embeddings directly.
+	QuantizationNone Quantization = "none"
+	// QuantizationInt8 scales each dimension to an 8-bit integer (4x smaller
+	// than float32).
+	QuantizationInt8 Quantization = "int8"
+	// QuantizationBinary keeps only the sign of each dimension (32x smaller
+	// than float32), compared by Hamming distance.
+	QuantizationBinary Quantization = "bit"
+)
+
+// Quantizations lists all the supported quantizations.
+var Quantizations = []Quantization{
+	QuantizationNone,
+	QuantizationInt8,
+	QuantizationBinary,
+}
+
+func QuantizationFromString(s string) (Quantization, error) {
+	for _, quantization := range Quantizations {
+		if string(quantization) == s {
+			return quantization, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown quantization: %s", s)
+}
+
+// ErrNoFullPrecision is returned for operations needing the full-precision
+// embeddings of a model which has had them dropped.
+var ErrNoFullPrecision = errors.New("full-precision embeddings were dropped")
+
+// quantizedCandidatesPerResult is how many features are taken from the
+// quantized search for each result, to be rescored at full precision.
+const quantizedCandidatesPerResult = 20
+
+// quantize returns the SQL expression quantizing the float32 embedding `expr`.
+func (q Quantization) quantize(expr string) string {
+	switch q {
+	case QuantizationInt8:
+		return fmt.Sprintf("vec_quantize_int8(%s, 'unit')", expr)
+	case QuantizationBinary:
+		return fmt.Sprintf("vec_quantize_binary(%s)", expr)
+	}
+
+	return expr
+}
+
+// distance returns the SQL expression for the distance between the stored,
+// quantized embedding `stored` and the float32 embedding `query`.
+func (q Quantization) distance(stored string, query string) string {
+	switch q {
+	case QuantizationInt8:
+		return fmt.Sprintf("vec_distance_cosine(vec_int8(%s), %s)", stored, q.quantize(query))
+	case QuantizationBinary:
+		return fmt.Sprintf("vec_distance_hamming(vec_bit(%s), %s)", stored, q.quantize(query))
+	}
+
+	return fmt.Sprintf("vec_distance_cosine(%s, %s)", stored, query)
+}
+
+// cosineDistance converts a distance returned by [Quantization.distance] to
+// (an estimate of) the cosine distance, so that it is comparable to the
+// distances of full-precision embeddings.
+//
+// The fraction of differing sign bits estimates the angle between two
+// embeddings as a fraction of π, as for random hyperplane hashing.
+func (q Quantization) cosineDistance(distance float64, dimensions int) float64 {
+	if q != QuantizationBinary {
+		return distance
+	}
+
+	return 1 - math.Cos(math.Pi*distance/float64(dimensions))
+}
+
+// ModelQuantization returns the quantization used for the model's embeddings.
+func (s *SQLiteVec) ModelQuantization(model Model) (Quantization, error) {
+	metadata, ok := s.models[model]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownModel, model)
+	}
+
+	return metadata.quantization, nil
+}
+
+// KeepsFullPrecision reports whether the model's full-precision embeddings are
+// kept.
+func (s *SQLiteVec) KeepsFullPrecision(model Model) bool {
+	return s.models[model].fullPrecision
+}
+
+// SetQuantization changes how the model's embeddings are quantized, and
+// (re)builds the quantized embeddings from the full-precision ones.
+//
+// Other processes using the database only pick up the change once they reopen
+// it.
+func (s *SQLiteVec) SetQuantization(
+	ctx context.Context,
+	model Model,
+	quantization Quantization,
+) (err error) {
+	ctx, span := startSQLiteSpan(
+		ctx,
+		"SetQuantization",
+		attribute.String("model", model.String()),
+		attribute.String("quantization", string(quantization)),
+	)
+	defer func() { endSpan(span, err) }()
+
+	metadata, ok := s.models[model]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownModel, model)
+	}
+
+	// The quantized embeddings are the only ones left, so can't be rebuilt.
+	if !metadata.fullPrecision {
+		if quantization == metadata.quantization {
+			return nil
+		}
+
+		return fmt.Errorf("requantizing %s: %w", model, ErrNoFullPrecision)
+	}
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(
+			ctx,
+			`
+				UPDATE embedding_models
+				SET quantization = ?
+				WHERE id = ?
+			`,
+			quantization,
+			model,
+		); err != nil {
+			return fmt.Errorf("updating model quantization: %w", err)
+		}
+
+		if _, err := tx.ExecContext(
+			ctx,
+			`
+				DELETE FROM quantized_embeddings
+				WHERE embedding_model_id = ?
+			`,
+			model,
+		); err != nil {
+			return fmt.Errorf("deleting quantized embeddings: %w", err)
+		}
+
+		if quantization == QuantizationNone {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(
+			ctx,
+			fmt.Sprintf(
+				`
+					INSERT INTO quantized_embeddings (
+						word_feature_id,
+						embedding_model_id,
+						embedding
+					)
+					SELECT word_feature_id, embedding_model_id, %s
+					FROM embeddings
+					WHERE embedding_model_id = ?
+				`,
+				quantization.quantize("embedding"),
+			),
+			model,
+		); err != nil {
+			return fmt.Errorf("quantizing embeddings: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	metadata.quantization = quantization
+	s.models[model] = metadata
+
+	return nil
+}
+
+// DropFullPrecision deletes the full-precision embeddings of a quantized
+// model, so that only its quantized embeddings are stored. Its searches then
+// rank on the quantized distances without rescoring, which is less accurate,
+// and it can no longer be requantized or have variants derived from it.
+// Embeddings added afterwards are only stored quantized.
+//
+// Returns the number of embeddings deleted. Other processes using the database
+// only pick up the change once they reopen it.
+func (s *SQLiteVec) DropFullPrecision(ctx context.Context, model Model) (_ int64, err error) {
+	ctx, span := startSQLiteSpan(ctx, "DropFullPrecision", attribute.String("model", model.String()))
+	defer func() { endSpan(span, err) }()
+
+	metadata, ok := s.models[model]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownModel, model)
+	}
+
+	if metadata.quantization == QuantizationNone {
+		return 0, fmt.Errorf("model %s isn't quantized, so needs its full-precision embeddings", model)
+	}
+
+	var deleted int64
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(
+			ctx,
+			`
+				DELETE FROM embeddings
+				WHERE embedding_model_id = ?
+			`,
+			model,
+		)
+		if err != nil {
+			return fmt.Errorf("deleting full-precision embeddings: %w", err)
+		}
+
+		deleted, err = result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("checking deleted embeddings: %w", err)
+		}
+
+		if _, err := tx.ExecContext(
+			ctx,
+			`
+				UPDATE embedding_models
+				SET full_precision = 0
+				WHERE id = ?
+			`,
+			model,
+		); err != nil {
+			return fmt.Errorf("updating model: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	metadata.fullPrecision = false
+	s.models[model] = metadata
+
+	return deleted, nil
+}
+
+// addQuantizedEmbedding stores the quantized form of a feature's embedding, if
+// the model is quantized.
+func (s *SQLiteVec) addQuantizedEmbedding(
+	ctx context.Context,
+	q querier,
+	featureID int64,
+	model Model,
+	embedding []byte,
+) error {
+	quantization := s.models[model].quantization
+
+	if quantization == "" || quantization == QuantizationNone {
+		return nil
+	}
+
+	if _, err := q.ExecContext(
+		ctx,
+		fmt.Sprintf(
+			`
+				INSERT INTO quantized_embeddings (
+					word_feature_id,
+					embedding_model_id,
+					embedding
+				) VALUES (?, ?, %s)
+				ON CONFLICT(word_feature_id, embedding_model_id) DO UPDATE SET
+					embedding = excluded.embedding
+			`,
+			quantization.quantize("?"),
+		),
+		featureID,
+		model,
+		embedding,
+	); err != nil {
+		return fmt.Errorf("inserting quantized embedding: %w", err)
+	}
+
+	return nil
+}
+
+// Compact rebuilds the database file to reclaim the space of deleted rows, and
+// truncates the write-ahead log.
+func (s *SQLiteVec) Compact(ctx context.Context) (err error) {
+	ctx, span := startSQLiteSpan(ctx, "Compact")
+	defer func() { endSpan(span, err) }()
+
+	if _, err := s.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return fmt.Errorf("vacuuming database: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("checkpointing write-ahead log: %w", err)
+	}
+
+	return nil
+}
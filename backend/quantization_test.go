@@ -0,0 +1,86 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDropFullPrecision(t *testing.T) {
+	const model = ModelAppleNLContextualEmbedding
+
+	ctx := context.Background()
+	s := newTestSQLiteVec(t)
+
+	embedding := func(sign float32) Embedding {
+		e := make(Embedding, 512)
+		for i := range e {
+			e[i] = sign
+		}
+
+		return e
+	}
+
+	addWord := func(word string, sign float32) {
+		t.Helper()
+
+		if _, err := s.AddDefinition(ctx, Definition{
+			Word: Word{Word: word, Definition: word},
+			Features: []Feature{{
+				Phrase:     word,
+				Embeddings: map[Model]Embedding{model: embedding(sign)},
+			}},
+		}); err != nil {
+			t.Fatalf("adding %s: %v", word, err)
+		}
+	}
+
+	if _, err := s.DropFullPrecision(ctx, model); err == nil {
+		t.Fatal("expected dropping an unquantized model's embeddings to fail")
+	}
+
+	addWord("up", 1)
+
+	if err := s.SetQuantization(ctx, model, QuantizationBinary); err != nil {
+		t.Fatalf("quantizing: %v", err)
+	}
+
+	deleted, err := s.DropFullPrecision(ctx, model)
+	if err != nil {
+		t.Fatalf("dropping full precision: %v", err)
+	}
+
+	if deleted != 1 {
+		t.Errorf("deleted %d embeddings, expected 1", deleted)
+	}
+
+	// Words added afterwards are only stored quantized.
+	addWord("down", -1)
+
+	if n := countRows(t, s, "embeddings"); n != 0 {
+		t.Errorf("%d full-precision embeddings left, expected none", n)
+	}
+
+	if n := countRows(t, s, "quantized_embeddings"); n != 2 {
+		t.Errorf("%d quantized embeddings, expected 2", n)
+	}
+
+	results, err := s.RelatedWords(ctx, model, embedding(1), 2, false)
+	if err != nil {
+		t.Fatalf("searching: %v", err)
+	}
+
+	if len(results) != 2 || results[0].Word.Word != "up" {
+		t.Fatalf("expected up then down, got %+v", results)
+	}
+
+	// The Hamming distances are converted to cosine distances: identical and
+	// opposite embeddings.
+	if results[0].Distance != 0 || results[1].Distance != 2 {
+		t.Errorf("got distances %v and %v, expected 0 and 2", results[0].Distance, results[1].Distance)
+	}
+
+	if err := s.SetQuantization(ctx, model, QuantizationInt8); !errors.Is(err, ErrNoFullPrecision) {
+		t.Errorf("expected requantizing to fail with ErrNoFullPrecision, got %v", err)
+	}
+}
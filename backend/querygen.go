@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrQueryContainsWord is returned when a generated query gives away the word
+// it is meant to find.
+var ErrQueryContainsWord = errors.New("generated query contains the word")
+
+// GenerateQuery asks the completion model for a realistic search query that
+// someone looking for the word might type, without using the word itself.
+func (s *SwamaAPI) GenerateQuery(ctx context.Context, word Word) (string, error) {
+	completion, err := s.Complete(
+		ctx,
+		"You are helping to evaluate a reverse dictionary, where people describe a word or phrase they can't remember, and the dictionary finds it. Given a word and its definition, write one realistic query that a person looking for this word might type, in the style of \"what's the word for...\" or a casual description of its meaning. The query must not contain the word itself, or any part of it. Vary the phrasing, and don't copy the definition verbatim. You may think for a bit. Output only the query, on a single line, without quotes.",
+		fmt.Sprintf("Word: %s\nDefinition:\n%s\n", word.Word, word.Definition),
+	)
+	if err != nil {
+		return "", fmt.Errorf("generating query: %w", err)
+	}
+
+	query := strings.Trim(PruneThinking(completion), "\"' \n")
+
+	if query == "" {
+		return "", errors.New("no query found in completion")
+	}
+
+	if strings.Contains(strings.ToLower(query), strings.ToLower(word.Word)) {
+		return "", fmt.Errorf("%w: %q", ErrQueryContainsWord, query)
+	}
+
+	return query, nil
+}
+
+// RewriteQuery asks the completion model to rephrase a query which didn't
+// closely match any word, e.g. by correcting spelling mistakes or describing
+// the meaning more plainly, so that it can be searched for again.
+func (s *SwamaAPI) RewriteQuery(ctx context.Context, query string) (string, error) {
+	completion, err := s.Complete(
+		ctx,
+		"You are helping a reverse dictionary of slang, where people describe a word or phrase they can't remember, and the dictionary finds it. The following description didn't closely match any word. Rewrite it as a clear, concise description of the meaning of the word they are probably looking for, correcting any spelling mistakes and keeping to what they meant. Do not guess the word itself. You may think for a bit. Output only the rewritten description, on a single line, without quotes.",
+		query,
+	)
+	if err != nil {
+		return "", fmt.Errorf("rewriting query: %w", err)
+	}
+
+	rewritten := strings.Trim(PruneThinking(completion), "\"' \n")
+
+	if rewritten == "" {
+		return "", errors.New("no query found in completion")
+	}
+
+	return rewritten, nil
+}
@@ -0,0 +1,484 @@
+package backend
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// QueryLogMode selects whether, and how, searches are logged for analytics.
+type QueryLogMode string
+
+const (
+	// QueryLogOff doesn't log searches.
+	QueryLogOff QueryLogMode = "off"
+	// QueryLogHashed logs searches with only a hash of the query, so that
+	// repeated queries can be counted without storing what was searched for.
+	// The hash is keyed, so that queries can't be recovered by hashing guesses
+	// without the key.
+	QueryLogHashed QueryLogMode = "hashed"
+	// QueryLogRaw logs searches with the query itself.
+	QueryLogRaw QueryLogMode = "raw"
+)
+
+// QueryLogModes lists all the query log modes.
+var QueryLogModes = []QueryLogMode{
+	QueryLogOff,
+	QueryLogHashed,
+	QueryLogRaw,
+}
+
+// QueryLogModeFromString parses a query log mode name.
+func QueryLogModeFromString(s string) (QueryLogMode, error) {
+	mode := QueryLogMode(s)
+
+	if !slices.Contains(QueryLogModes, mode) {
+		return "", fmt.Errorf("unknown query log mode: %s", s)
+	}
+
+	return mode, nil
+}
+
+// LoggedSearch is a search to record in the query log.
+type LoggedSearch struct {
+	Query string
+	Safe  bool
+	// ZeroResults is whether the search was answered with no results.
+	ZeroResults bool
+	Results     map[Model][]SimilarDefinition
+	Latencies   map[Model]time.Duration
+}
+
+// hashQuery returns the hash identifying a query in the query log, an HMAC
+// of the query with the key. Queries differing only in case or surrounding
+// whitespace are counted together.
+func hashQuery(key []byte, query string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(query))))
+
+	return mac.Sum(nil)
+}
+
+// LogQuery records a search in the query log, identifying the query by its
+// hash with the key. The query itself is only stored in [QueryLogRaw] mode.
+func (s *SQLiteVec) LogQuery(
+	ctx context.Context,
+	mode QueryLogMode,
+	hashKey []byte,
+	search LoggedSearch,
+) (err error) {
+	if mode == QueryLogOff {
+		return nil
+	}
+
+	ctx, span := startSQLiteSpan(ctx, "LogQuery", attribute.String("mode", string(mode)))
+	defer func() { endSpan(span, err) }()
+
+	var query *string
+	if mode == QueryLogRaw {
+		query = &search.Query
+	}
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var id int64
+
+		if err := tx.QueryRowContext(
+			ctx,
+			`
+				INSERT INTO query_log (query, query_hash, safe, zero_results)
+				VALUES (?, ?, ?, ?)
+				RETURNING id
+			`,
+			query,
+			hashQuery(hashKey, search.Query),
+			search.Safe,
+			search.ZeroResults,
+		).Scan(&id); err != nil {
+			return fmt.Errorf("inserting query log: %w", err)
+		}
+
+		for model, results := range search.Results {
+			var topWordID *int64
+			if len(results) > 0 {
+				topWordID = &results[0].ID
+			}
+
+			if _, err := tx.ExecContext(
+				ctx,
+				`
+					INSERT INTO query_log_models (query_log_id, embedding_model_id, results, latency_ms, top_word_id)
+					VALUES (?, ?, ?, ?, ?)
+				`,
+				id,
+				model,
+				len(results),
+				float64(search.Latencies[model].Microseconds())/1000,
+				topWordID,
+			); err != nil {
+				return fmt.Errorf("inserting query log model: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// queryLogBuffer is the number of searches which can be waiting to be logged.
+// Further searches are dropped, rather than slowing searching down.
+const queryLogBuffer = 256
+
+// queuedSearch is a search waiting to be logged.
+type queuedSearch struct {
+	ctx    context.Context
+	search LoggedSearch
+}
+
+// QueryLogger logs searches in the background, so that writing the query log
+// doesn't add to the latency of searches.
+type QueryLogger struct {
+	db      *SQLiteVec
+	mode    QueryLogMode
+	hashKey []byte
+	done    chan struct{}
+
+	// mu guards sending to searches, which is closed when the logger is, as
+	// handlers still running after the server shuts down may log searches.
+	mu       sync.Mutex
+	closed   bool
+	searches chan queuedSearch
+}
+
+// NewQueryLogger starts logging searches to the database in the given mode,
+// hashing the queries with the key. A key is required in [QueryLogHashed]
+// mode. The logger must be closed to log the searches still waiting.
+func NewQueryLogger(db *SQLiteVec, mode QueryLogMode, hashKey []byte) (*QueryLogger, error) {
+	if mode == QueryLogHashed && len(hashKey) == 0 {
+		return nil, errors.New("a hash key is required to log hashed queries")
+	}
+
+	l := &QueryLogger{
+		db:       db,
+		mode:     mode,
+		hashKey:  hashKey,
+		searches: make(chan queuedSearch, queryLogBuffer),
+		done:     make(chan struct{}),
+	}
+
+	go l.run()
+
+	return l, nil
+}
+
+func (l *QueryLogger) run() {
+	defer close(l.done)
+
+	for queued := range l.searches {
+		// A search is still answered if it can't be logged.
+		if err := l.db.LogQuery(queued.ctx, l.mode, l.hashKey, queued.search); err != nil {
+			slog.WarnContext(queued.ctx, "logging query failed", slog.Any("error", err))
+		}
+	}
+}
+
+// Log queues the search to be logged, or drops it if too many searches are
+// already waiting or the logger is closed.
+func (l *QueryLogger) Log(ctx context.Context, search LoggedSearch) {
+	// The results are still being ranked after the search is queued, so are
+	// copied.
+	results := make(map[Model][]SimilarDefinition, len(search.Results))
+
+	for model, definitions := range search.Results {
+		results[model] = slices.Clone(definitions)
+	}
+
+	search.Results = results
+	search.Latencies = maps.Clone(search.Latencies)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		slog.WarnContext(ctx, "query log is closed, dropping search")
+
+		return
+	}
+
+	select {
+	case l.searches <- queuedSearch{ctx: context.WithoutCancel(ctx), search: search}:
+	default:
+		slog.WarnContext(ctx, "query log is backed up, dropping search")
+	}
+}
+
+// Close waits for the searches still waiting to be logged. Searches logged
+// afterwards are dropped.
+func (l *QueryLogger) Close() {
+	l.mu.Lock()
+
+	if !l.closed {
+		l.closed = true
+		close(l.searches)
+	}
+
+	l.mu.Unlock()
+
+	<-l.done
+}
+
+// QueryCount is how often a query was searched for.
+type QueryCount struct {
+	// Query is omitted if the query was logged hashed.
+	Query     string `json:"query,omitempty"`
+	QueryHash string `json:"query_hash"`
+	Searches  int    `json:"searches"`
+}
+
+// ModelQueryStats summarises a model's logged searches.
+type ModelQueryStats struct {
+	Model    Model `json:"model"`
+	Searches int   `json:"searches"`
+	// ZeroResults is the number of searches the model returned nothing for.
+	ZeroResults   int     `json:"zero_results"`
+	MeanLatencyMS float64 `json:"mean_latency_ms"`
+	MaxLatencyMS  float64 `json:"max_latency_ms"`
+}
+
+// ModelDisagreement is how often two models disagree on the best word for a
+// query.
+type ModelDisagreement struct {
+	Model      Model `json:"model"`
+	OtherModel Model `json:"other_model"`
+	// Searches is the number of searches both models returned results for.
+	Searches int `json:"searches"`
+	// Rate is the fraction of those searches where the models' top words
+	// differed.
+	Rate float64 `json:"rate"`
+}
+
+// QueryAnalytics summarises the query log.
+type QueryAnalytics struct {
+	Since             time.Time           `json:"since"`
+	Searches          int                 `json:"searches"`
+	ZeroResults       int                 `json:"zero_results"`
+	TopQueries        []QueryCount        `json:"top_queries"`
+	ZeroResultQueries []QueryCount        `json:"zero_result_queries"`
+	Models            []ModelQueryStats   `json:"models"`
+	Disagreement      []ModelDisagreement `json:"disagreement"`
+}
+
+// GetQueryAnalytics summarises the searches logged since the given time,
+// listing up to `limit` of the most frequent queries.
+func (s *SQLiteVec) GetQueryAnalytics(
+	ctx context.Context,
+	since time.Time,
+	limit int,
+) (_ *QueryAnalytics, err error) {
+	ctx, span := startSQLiteSpan(ctx, "GetQueryAnalytics", attribute.Int("limit", limit))
+	defer func() { endSpan(span, err) }()
+
+	analytics := QueryAnalytics{
+		Since: since,
+	}
+
+	if err := s.db.QueryRowContext(
+		ctx,
+		`
+			SELECT COUNT(*), COALESCE(SUM(zero_results), 0)
+			FROM query_log
+			WHERE created_at >= ?
+		`,
+		since.Unix(),
+	).Scan(&analytics.Searches, &analytics.ZeroResults); err != nil {
+		return nil, fmt.Errorf("counting searches: %w", err)
+	}
+
+	analytics.TopQueries, err = s.queryCounts(ctx, since, false, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics.ZeroResultQueries, err = s.queryCounts(ctx, since, true, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics.Models, err = s.modelQueryStats(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics.Disagreement, err = s.modelDisagreement(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return &analytics, nil
+}
+
+// queryCounts returns up to `limit` of the most frequent queries since the
+// given time, optionally only counting searches with zero results.
+func (s *SQLiteVec) queryCounts(
+	ctx context.Context,
+	since time.Time,
+	zeroResults bool,
+	limit int,
+) ([]QueryCount, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+			SELECT MAX(query), query_hash, COUNT(*) AS searches
+			FROM query_log
+			WHERE created_at >= ?
+				AND (? = 0 OR zero_results = 1)
+			GROUP BY query_hash
+			ORDER BY searches DESC, MAX(created_at) DESC
+			LIMIT ?
+		`,
+		since.Unix(),
+		zeroResults,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying query counts: %w", err)
+	}
+
+	defer rows.Close()
+
+	var counts []QueryCount
+
+	for rows.Next() {
+		var (
+			count QueryCount
+			query *string
+			hash  []byte
+		)
+
+		if err := rows.Scan(&query, &hash, &count.Searches); err != nil {
+			return nil, fmt.Errorf("scanning query count: %w", err)
+		}
+
+		if query != nil {
+			count.Query = *query
+		}
+
+		count.QueryHash = hex.EncodeToString(hash)
+		counts = append(counts, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating query counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// modelQueryStats summarises each model's searches since the given time.
+func (s *SQLiteVec) modelQueryStats(ctx context.Context, since time.Time) ([]ModelQueryStats, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+			SELECT
+				qlm.embedding_model_id,
+				COUNT(*),
+				COUNT(*) FILTER (WHERE qlm.results = 0),
+				AVG(qlm.latency_ms),
+				MAX(qlm.latency_ms)
+			FROM query_log_models qlm
+			JOIN query_log ql ON ql.id = qlm.query_log_id
+			WHERE ql.created_at >= ?
+			GROUP BY qlm.embedding_model_id
+			ORDER BY qlm.embedding_model_id
+		`,
+		since.Unix(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying model stats: %w", err)
+	}
+
+	defer rows.Close()
+
+	var stats []ModelQueryStats
+
+	for rows.Next() {
+		var s ModelQueryStats
+
+		if err := rows.Scan(
+			&s.Model,
+			&s.Searches,
+			&s.ZeroResults,
+			&s.MeanLatencyMS,
+			&s.MaxLatencyMS,
+		); err != nil {
+			return nil, fmt.Errorf("scanning model stats: %w", err)
+		}
+
+		stats = append(stats, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating model stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// modelDisagreement returns how often each pair of models disagreed on the
+// top word of the searches since the given time.
+func (s *SQLiteVec) modelDisagreement(ctx context.Context, since time.Time) ([]ModelDisagreement, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+			SELECT
+				a.embedding_model_id,
+				b.embedding_model_id,
+				COUNT(*),
+				AVG(a.top_word_id != b.top_word_id)
+			FROM query_log_models a
+			JOIN query_log_models b
+				ON b.query_log_id = a.query_log_id
+				AND b.embedding_model_id > a.embedding_model_id
+			JOIN query_log ql ON ql.id = a.query_log_id
+			WHERE ql.created_at >= ?
+				AND a.top_word_id IS NOT NULL
+				AND b.top_word_id IS NOT NULL
+			GROUP BY a.embedding_model_id, b.embedding_model_id
+			ORDER BY a.embedding_model_id, b.embedding_model_id
+		`,
+		since.Unix(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying model disagreement: %w", err)
+	}
+
+	defer rows.Close()
+
+	var disagreement []ModelDisagreement
+
+	for rows.Next() {
+		var d ModelDisagreement
+
+		if err := rows.Scan(&d.Model, &d.OtherModel, &d.Searches, &d.Rate); err != nil {
+			return nil, fmt.Errorf("scanning model disagreement: %w", err)
+		}
+
+		disagreement = append(disagreement, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating model disagreement: %w", err)
+	}
+
+	return disagreement, nil
+}
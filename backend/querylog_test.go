@@ -0,0 +1,113 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"testing"
+	"time"
+)
+
+func TestQueryLogger(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSQLiteVec(t)
+	key := []byte("secret")
+
+	if _, err := NewQueryLogger(s, QueryLogHashed, nil); err == nil {
+		t.Fatal("expected logging hashed queries without a key to fail")
+	}
+
+	logger, err := NewQueryLogger(s, QueryLogHashed, key)
+	if err != nil {
+		t.Fatalf("creating query logger: %v", err)
+	}
+
+	// The request's context is canceled once the search is answered, before
+	// the search is logged.
+	requestCtx, cancel := context.WithCancel(ctx)
+
+	for _, query := range []string{"a tiny bit", " A Tiny Bit"} {
+		logger.Log(requestCtx, LoggedSearch{
+			Query:       query,
+			ZeroResults: true,
+			Results:     map[Model][]SimilarDefinition{ModelAppleNLContextualEmbedding: nil},
+			Latencies:   map[Model]time.Duration{ModelAppleNLContextualEmbedding: time.Millisecond},
+		})
+	}
+
+	cancel()
+	logger.Close()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT query, query_hash FROM query_log")
+	if err != nil {
+		t.Fatalf("querying query log: %v", err)
+	}
+	defer rows.Close()
+
+	var hashes [][]byte
+
+	for rows.Next() {
+		var (
+			query *string
+			hash  []byte
+		)
+
+		if err := rows.Scan(&query, &hash); err != nil {
+			t.Fatalf("scanning query log: %v", err)
+		}
+
+		if query != nil {
+			t.Errorf("query %q stored in hashed mode", *query)
+		}
+
+		hashes = append(hashes, hash)
+	}
+
+	if err := rows.Err(); err != nil {
+		t.Fatalf("reading query log: %v", err)
+	}
+
+	if len(hashes) != 2 {
+		t.Fatalf("%d searches logged, expected 2", len(hashes))
+	}
+
+	if !bytes.Equal(hashes[0], hashes[1]) {
+		t.Error("queries differing in case and whitespace were hashed differently")
+	}
+
+	unkeyed := sha256.Sum256([]byte("a tiny bit"))
+
+	if bytes.Equal(hashes[0], unkeyed[:]) || bytes.Equal(hashes[0], hashQuery([]byte("guess"), "a tiny bit")) {
+		t.Error("query hash doesn't depend on the key")
+	}
+
+	if n := countRows(t, s, "query_log_models"); n != 2 {
+		t.Errorf("%d model rows logged, expected 2", n)
+	}
+}
+
+func TestQueryLoggerLogAfterClose(t *testing.T) {
+	s := newTestSQLiteVec(t)
+
+	logger, err := NewQueryLogger(s, QueryLogRaw, nil)
+	if err != nil {
+		t.Fatalf("creating query logger: %v", err)
+	}
+
+	search := LoggedSearch{
+		Query:   "a tiny bit",
+		Results: map[Model][]SimilarDefinition{ModelAppleNLContextualEmbedding: nil},
+	}
+
+	logger.Log(context.Background(), search)
+	logger.Close()
+
+	// Handlers still running after the server has shut down may log searches,
+	// which must be dropped rather than panic.
+	logger.Log(context.Background(), search)
+	logger.Close()
+
+	if n := countRows(t, s, "query_log"); n != 1 {
+		t.Errorf("%d searches logged, expected only the one before closing", n)
+	}
+}
@@ -0,0 +1,709 @@
+package backend
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrNoActiveRanker is returned when no ranker has been activated, so results
+// are ranked by distance alone.
+var ErrNoActiveRanker = errors.New("no active ranker")
+
+// Ranker re-ranks search results using what has been learnt from user
+// feedback. Each model's distances are calibrated into probabilities of the
+// result being relevant, and the probabilities of all the models are fused
+// into a single score.
+type Ranker struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Bias is the intercept of the fusion of the models.
+	Bias   float64            `json:"bias"`
+	Models []ModelCalibration `json:"models"`
+	// Samples is the number of feedback samples the ranker was trained on.
+	Samples int `json:"samples"`
+	// AUC is the area under the ROC curve of the ranker's scores on the
+	// held-out feedback.
+	AUC float64 `json:"auc"`
+	// BaselineAUC is the AUC of ranking the held-out feedback by distance
+	// alone.
+	BaselineAUC float64 `json:"baseline_auc"`
+	// Active is whether the ranker is applied to searches.
+	Active bool `json:"active"`
+}
+
+// ModelCalibration is what a [Ranker] has learnt about one model.
+type ModelCalibration struct {
+	Model Model `json:"model"`
+	// Intercept and Slope map a distance to the log-odds of the result being
+	// relevant.
+	Intercept float64 `json:"intercept"`
+	Slope     float64 `json:"slope"`
+	// Weight is the weight of the model's probabilities in the fused score.
+	Weight float64 `json:"weight"`
+}
+
+// Probability returns the calibrated probability of a result at the distance
+// being relevant.
+func (c ModelCalibration) Probability(distance float64) float64 {
+	return sigmoid(c.Intercept + c.Slope*distance)
+}
+
+// model returns the calibration of the model, if the ranker has one.
+func (r *Ranker) model(model Model) (ModelCalibration, bool) {
+	i := slices.IndexFunc(r.Models, func(c ModelCalibration) bool {
+		return c.Model == model
+	})
+	if i < 0 {
+		return ModelCalibration{}, false
+	}
+
+	return r.Models[i], true
+}
+
+// Score returns the fused probability of a word being relevant, given its
+// distance in the results of each model which returned it. Models which
+// didn't return the word contribute nothing.
+func (r *Ranker) Score(distances map[Model]float64) float64 {
+	logit := r.Bias
+
+	for _, calibration := range r.Models {
+		if distance, ok := distances[calibration.Model]; ok {
+			logit += calibration.Weight * calibration.Probability(distance)
+		}
+	}
+
+	return sigmoid(logit)
+}
+
+// Apply sets the score of each model's results to its calibrated probability,
+// in place, like [DistanceThresholds.Apply] sets their confidence. It returns
+// up to `limit` of the words returned by any model, ordered by their fused
+// score. Each fused result is a copy of the model result with the highest
+// probability, with its score replaced by the fused score.
+func (r *Ranker) Apply(results map[Model][]SimilarDefinition, limit int) []SimilarDefinition {
+	type candidate struct {
+		best      SimilarDefinition
+		distances map[Model]float64
+	}
+
+	candidates := make(map[int64]*candidate)
+
+	for model, definitions := range results {
+		calibration, ok := r.model(model)
+		if !ok {
+			continue
+		}
+
+		for i := range definitions {
+			definitions[i].Score = calibration.Probability(definitions[i].Distance)
+
+			c, ok := candidates[definitions[i].ID]
+			if !ok {
+				c = &candidate{
+					best:      definitions[i],
+					distances: make(map[Model]float64),
+				}
+				candidates[definitions[i].ID] = c
+			} else if definitions[i].Score > c.best.Score {
+				c.best = definitions[i]
+			}
+
+			c.distances[model] = definitions[i].Distance
+		}
+	}
+
+	fused := make([]SimilarDefinition, 0, len(candidates))
+
+	for _, c := range candidates {
+		definition := c.best
+		definition.Score = r.Score(c.distances)
+		fused = append(fused, definition)
+	}
+
+	slices.SortFunc(fused, func(a, b SimilarDefinition) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.ID, b.ID))
+	})
+
+	return fused[:min(limit, len(fused))]
+}
+
+// RankerSample is a piece of user feedback, as seen by the models: the
+// distance of the word in the results of each model which returned it for the
+// query.
+type RankerSample struct {
+	Query     string            `json:"query"`
+	WordID    int64             `json:"word_id"`
+	Distances map[Model]float64 `json:"distances"`
+	// Relevant is whether the user found the word relevant, or the word they
+	// meant.
+	Relevant bool `json:"relevant"`
+}
+
+// RankerSamples searches for the query of each piece of feedback, returning
+// the distance of its word in the top `k` results of each model. `k` should
+// match the limit of the searches the ranker will be applied to. Feedback on
+// words which no model returns any more is skipped.
+func (s *Searcher) RankerSamples(
+	ctx context.Context,
+	feedback []Feedback,
+	k int,
+) (_ []RankerSample, err error) {
+	ctx, span := tracer.Start(
+		ctx,
+		"Searcher.RankerSamples",
+		trace.WithAttributes(
+			attribute.Int("feedback", len(feedback)),
+			attribute.Int("k", k),
+		),
+	)
+	defer func() { endSpan(span, err) }()
+
+	// The distances of the words returned for each query, by model.
+	queryDistances := make(map[string]map[int64]map[Model]float64)
+
+	var samples []RankerSample
+
+	for _, f := range feedback {
+		distances, ok := queryDistances[f.Query]
+		if !ok {
+			results, err := s.Search(ctx, f.Query, k, SearchModeAuto, false)
+			if err != nil {
+				return nil, fmt.Errorf("searching for %q: %w", f.Query, err)
+			}
+
+			distances = make(map[int64]map[Model]float64)
+
+			for model, definitions := range results {
+				for _, definition := range definitions {
+					if distances[definition.ID] == nil {
+						distances[definition.ID] = make(map[Model]float64)
+					}
+
+					distances[definition.ID][model] = definition.Distance
+				}
+			}
+
+			queryDistances[f.Query] = distances
+		}
+
+		if len(distances[f.WordID]) == 0 {
+			continue
+		}
+
+		samples = append(samples, RankerSample{
+			Query:     f.Query,
+			WordID:    f.WordID,
+			Distances: distances[f.WordID],
+			Relevant:  f.Verdict != VerdictIrrelevant,
+		})
+	}
+
+	span.SetAttributes(attribute.Int("samples", len(samples)))
+
+	return samples, nil
+}
+
+// rankerHoldout is the reciprocal of the fraction of queries held out from
+// training, to evaluate rankers on.
+const rankerHoldout = 5
+
+// SplitRankerSamples splits the samples into those to train a ranker on, and
+// those to evaluate it on. The split is by query, so that a ranker is
+// evaluated on queries it hasn't seen, and is stable as feedback accumulates.
+func SplitRankerSamples(samples []RankerSample) (train, heldOut []RankerSample) {
+	for _, sample := range samples {
+		hash := fnv.New32a()
+		hash.Write([]byte(sample.Query))
+
+		if hash.Sum32()%rankerHoldout == 0 {
+			heldOut = append(heldOut, sample)
+		} else {
+			train = append(train, sample)
+		}
+	}
+
+	return train, heldOut
+}
+
+// rankerL2 is the L2 regularisation of the ranker's coefficients, which keeps
+// them finite when the feedback is separable.
+const rankerL2 = 1
+
+// TrainRanker fits a ranker to the samples, by logistic regression. Each
+// model with both relevant and irrelevant feedback is calibrated on the
+// samples it returned, and then the calibrated models are fused.
+func TrainRanker(samples []RankerSample) (*Ranker, error) {
+	byModel := make(map[Model][]RankerSample)
+
+	for _, sample := range samples {
+		for model := range sample.Distances {
+			byModel[model] = append(byModel[model], sample)
+		}
+	}
+
+	ranker := &Ranker{
+		Samples: len(samples),
+	}
+
+	for _, model := range slices.Sorted(maps.Keys(byModel)) {
+		modelSamples := byModel[model]
+
+		features := make([][]float64, len(modelSamples))
+		labels := make([]bool, len(modelSamples))
+
+		for i, sample := range modelSamples {
+			features[i] = []float64{sample.Distances[model]}
+			labels[i] = sample.Relevant
+		}
+
+		if !slices.Contains(labels, true) || !slices.Contains(labels, false) {
+			continue
+		}
+
+		coefficients, err := fitLogistic(features, labels, rankerL2)
+		if err != nil {
+			return nil, fmt.Errorf("calibrating %s: %w", model, err)
+		}
+
+		ranker.Models = append(ranker.Models, ModelCalibration{
+			Model:     model,
+			Intercept: coefficients[0],
+			Slope:     coefficients[1],
+		})
+	}
+
+	if len(ranker.Models) == 0 {
+		return nil, errors.New("no model has both relevant and irrelevant feedback")
+	}
+
+	features := make([][]float64, len(samples))
+	labels := make([]bool, len(samples))
+
+	for i, sample := range samples {
+		features[i] = make([]float64, len(ranker.Models))
+
+		for j, calibration := range ranker.Models {
+			if distance, ok := sample.Distances[calibration.Model]; ok {
+				features[i][j] = calibration.Probability(distance)
+			}
+		}
+
+		labels[i] = sample.Relevant
+	}
+
+	coefficients, err := fitLogistic(features, labels, rankerL2)
+	if err != nil {
+		return nil, fmt.Errorf("fusing models: %w", err)
+	}
+
+	ranker.Bias = coefficients[0]
+
+	for i := range ranker.Models {
+		ranker.Models[i].Weight = coefficients[i+1]
+	}
+
+	return ranker, nil
+}
+
+// RankerAUC returns the area under the ROC curve of the scores of the samples,
+// i.e. the probability that a random relevant sample scores higher than a
+// random irrelevant one.
+func RankerAUC(samples []RankerSample, score func(RankerSample) float64) (float64, error) {
+	type scored struct {
+		score    float64
+		relevant bool
+	}
+
+	scores := make([]scored, len(samples))
+
+	var relevant int
+
+	for i, sample := range samples {
+		scores[i] = scored{score(sample), sample.Relevant}
+
+		if sample.Relevant {
+			relevant++
+		}
+	}
+
+	irrelevant := len(samples) - relevant
+
+	if relevant == 0 || irrelevant == 0 {
+		return 0, errors.New("both relevant and irrelevant samples are needed")
+	}
+
+	slices.SortFunc(scores, func(a, b scored) int {
+		return cmp.Compare(a.score, b.score)
+	})
+
+	// Sum the ranks of the relevant samples, with ties given their mean rank.
+	var rankSum float64
+
+	for i := 0; i < len(scores); {
+		j := i
+		for j < len(scores) && scores[j].score == scores[i].score {
+			j++
+		}
+
+		meanRank := float64(i+j+1) / 2
+
+		for _, s := range scores[i:j] {
+			if s.relevant {
+				rankSum += meanRank
+			}
+		}
+
+		i = j
+	}
+
+	u := rankSum - float64(relevant*(relevant+1))/2
+
+	return u / float64(relevant*irrelevant), nil
+}
+
+// BaselineScore scores a sample by its smallest distance in any model, which
+// is how results are ranked without a ranker.
+func BaselineScore(sample RankerSample) float64 {
+	best := math.Inf(-1)
+
+	for _, distance := range sample.Distances {
+		best = max(best, 1-distance)
+	}
+
+	return best
+}
+
+// ScoreSample scores a sample with the ranker.
+func (r *Ranker) ScoreSample(sample RankerSample) float64 {
+	return r.Score(sample.Distances)
+}
+
+// Rejection explains why the ranker shouldn't be activated, or is empty if it
+// should be. Its held-out AUC must improve on ranking by distance alone by at
+// least minImprovement, and mustn't be worse than the active ranker's, if
+// activeAUC is given.
+func (r *Ranker) Rejection(minImprovement float64, activeAUC *float64) string {
+	switch {
+	case r.AUC < r.BaselineAUC+minImprovement:
+		return fmt.Sprintf(
+			"held-out AUC %.3f doesn't improve on distance alone (%.3f) by %.3f",
+			r.AUC,
+			r.BaselineAUC,
+			minImprovement,
+		)
+	case activeAUC != nil && r.AUC < *activeAUC:
+		return fmt.Sprintf(
+			"held-out AUC %.3f is worse than the active ranker's (%.3f)",
+			r.AUC,
+			*activeAUC,
+		)
+	}
+
+	return ""
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// fitLogistic fits a logistic regression of the labels on the features by
+// Newton's method, returning the intercept followed by a coefficient for each
+// feature. The coefficients, but not the intercept, are L2 regularised.
+//
+// The features are standardised while fitting, so that the regularisation
+// doesn't depend on their scale: distances often only vary in the second
+// decimal place.
+func fitLogistic(features [][]float64, labels []bool, l2 float64) ([]float64, error) {
+	n := len(features[0]) + 1
+	beta := make([]float64, n)
+	x := make([]float64, n)
+
+	mean := make([]float64, n)
+	scale := make([]float64, n)
+
+	for _, row := range features {
+		for j, v := range row {
+			mean[j+1] += v / float64(len(features))
+		}
+	}
+
+	for _, row := range features {
+		for j, v := range row {
+			scale[j+1] += (v - mean[j+1]) * (v - mean[j+1]) / float64(len(features))
+		}
+	}
+
+	for j := 1; j < n; j++ {
+		scale[j] = math.Sqrt(scale[j])
+
+		if scale[j] == 0 {
+			scale[j] = 1
+		}
+	}
+
+	for range 100 {
+		gradient := make([]float64, n)
+		hessian := make([][]float64, n)
+
+		for j := range hessian {
+			hessian[j] = make([]float64, n)
+		}
+
+		for i, row := range features {
+			x[0] = 1
+
+			for j, v := range row {
+				x[j+1] = (v - mean[j+1]) / scale[j+1]
+			}
+
+			var logit float64
+
+			for j := range x {
+				logit += beta[j] * x[j]
+			}
+
+			p := sigmoid(logit)
+
+			var y float64
+			if labels[i] {
+				y = 1
+			}
+
+			for j := range x {
+				gradient[j] += (y - p) * x[j]
+
+				for k := range x {
+					hessian[j][k] += p * (1 - p) * x[j] * x[k]
+				}
+			}
+		}
+
+		for j := 1; j < n; j++ {
+			gradient[j] -= l2 * beta[j]
+			hessian[j][j] += l2
+		}
+
+		step, err := solve(hessian, gradient)
+		if err != nil {
+			return nil, err
+		}
+
+		var largest float64
+
+		for j := range beta {
+			beta[j] += step[j]
+			largest = max(largest, math.Abs(step[j]))
+		}
+
+		if largest < 1e-9 {
+			break
+		}
+	}
+
+	// Undo the standardisation.
+	for j := 1; j < n; j++ {
+		beta[j] /= scale[j]
+		beta[0] -= beta[j] * mean[j]
+	}
+
+	for _, b := range beta {
+		if math.IsNaN(b) || math.IsInf(b, 0) {
+			return nil, errors.New("logistic regression diverged")
+		}
+	}
+
+	return beta, nil
+}
+
+// solve solves the linear system `a x = b` by Gaussian elimination with partial
+// pivoting. Both `a` and `b` are overwritten.
+func solve(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+
+	for col := range n {
+		pivot := col
+
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, errors.New("singular system")
+		}
+
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+
+		for row := col + 1; row < n; row++ {
+			factor := a[row][col] / a[col][col]
+
+			for k := col; k < n; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+
+			b[row] -= factor * b[col]
+		}
+	}
+
+	x := make([]float64, n)
+
+	for row := n - 1; row >= 0; row-- {
+		sum := b[row]
+
+		for k := row + 1; k < n; k++ {
+			sum -= a[row][k] * x[k]
+		}
+
+		x[row] = sum / a[row][row]
+	}
+
+	return x, nil
+}
+
+// AddRanker stores a trained ranker. If `activate` is set, it replaces the
+// active ranker.
+func (s *SQLiteVec) AddRanker(ctx context.Context, ranker *Ranker, activate bool) (err error) {
+	ctx, span := startSQLiteSpan(ctx, "AddRanker", attribute.Bool("activate", activate))
+	defer func() { endSpan(span, err) }()
+
+	var createdAt int64
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		if activate {
+			if _, err := tx.ExecContext(
+				ctx,
+				`UPDATE rankers SET active = 0 WHERE active = 1`,
+			); err != nil {
+				return fmt.Errorf("deactivating ranker: %w", err)
+			}
+		}
+
+		if err := tx.QueryRowContext(
+			ctx,
+			`
+				INSERT INTO rankers (bias, samples, auc, baseline_auc, active)
+				VALUES (?, ?, ?, ?, ?)
+				RETURNING id, created_at
+			`,
+			ranker.Bias,
+			ranker.Samples,
+			ranker.AUC,
+			ranker.BaselineAUC,
+			activate,
+		).Scan(&ranker.ID, &createdAt); err != nil {
+			return fmt.Errorf("inserting ranker: %w", err)
+		}
+
+		for _, calibration := range ranker.Models {
+			if _, err := tx.ExecContext(
+				ctx,
+				`
+					INSERT INTO ranker_models (ranker_id, embedding_model_id, intercept, slope, weight)
+					VALUES (?, ?, ?, ?, ?)
+				`,
+				ranker.ID,
+				calibration.Model,
+				calibration.Intercept,
+				calibration.Slope,
+				calibration.Weight,
+			); err != nil {
+				return fmt.Errorf("inserting ranker model: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	ranker.CreatedAt = time.Unix(createdAt, 0)
+	ranker.Active = activate
+
+	return nil
+}
+
+// ActiveRanker returns the ranker applied to searches, or
+// [ErrNoActiveRanker].
+func (s *SQLiteVec) ActiveRanker(ctx context.Context) (_ *Ranker, err error) {
+	ctx, span := startSQLiteSpan(ctx, "ActiveRanker")
+	defer func() { endSpan(span, err) }()
+
+	ranker := Ranker{
+		Active: true,
+	}
+
+	var createdAt int64
+
+	if err := s.db.QueryRowContext(
+		ctx,
+		`
+			SELECT id, bias, samples, auc, baseline_auc, created_at
+			FROM rankers
+			WHERE active = 1
+		`,
+	).Scan(
+		&ranker.ID,
+		&ranker.Bias,
+		&ranker.Samples,
+		&ranker.AUC,
+		&ranker.BaselineAUC,
+		&createdAt,
+	); errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoActiveRanker
+	} else if err != nil {
+		return nil, fmt.Errorf("querying active ranker: %w", err)
+	}
+
+	ranker.CreatedAt = time.Unix(createdAt, 0)
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+			SELECT embedding_model_id, intercept, slope, weight
+			FROM ranker_models
+			WHERE ranker_id = ?
+			ORDER BY embedding_model_id
+		`,
+		ranker.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying ranker models: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var calibration ModelCalibration
+
+		if err := rows.Scan(
+			&calibration.Model,
+			&calibration.Intercept,
+			&calibration.Slope,
+			&calibration.Weight,
+		); err != nil {
+			return nil, fmt.Errorf("scanning ranker model: %w", err)
+		}
+
+		ranker.Models = append(ranker.Models, calibration)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating ranker models: %w", err)
+	}
+
+	return &ranker, nil
+}
@@ -0,0 +1,257 @@
+package backend
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSolve(t *testing.T) {
+	// The first pivot is zero, so the rows must be swapped.
+	a := [][]float64{
+		{0, 2, 1},
+		{1, 1, 1},
+		{2, 1, -1},
+	}
+	b := []float64{7, 6, 1}
+
+	x, err := solve(a, b)
+	if err != nil {
+		t.Fatalf("solving: %v", err)
+	}
+
+	for i, expected := range []float64{1, 2, 3} {
+		if math.Abs(x[i]-expected) > 1e-9 {
+			t.Errorf("x[%d] = %v, expected %v", i, x[i], expected)
+		}
+	}
+
+	singular := [][]float64{
+		{1, 2},
+		{2, 4},
+	}
+
+	if _, err := solve(singular, []float64{1, 2}); err == nil {
+		t.Error("expected a singular system to fail")
+	}
+}
+
+func TestFitLogistic(t *testing.T) {
+	t.Run("separable", func(t *testing.T) {
+		// Every distance below 0.5 is relevant, so without regularisation the
+		// slope would diverge to -∞.
+		var (
+			features [][]float64
+			labels   []bool
+		)
+
+		for i := range 20 {
+			distance := 0.3 + 0.01*float64(i)
+
+			features = append(features, []float64{distance})
+			labels = append(labels, distance < 0.4)
+		}
+
+		beta, err := fitLogistic(features, labels, rankerL2)
+		if err != nil {
+			t.Fatalf("fitting: %v", err)
+		}
+
+		if beta[1] >= 0 {
+			t.Fatalf("slope %v, expected closer results to be more relevant", beta[1])
+		}
+
+		// The boundary between the relevant and irrelevant distances is where
+		// the probability crosses a half.
+		if boundary := -beta[0] / beta[1]; math.Abs(boundary-0.395) > 0.01 {
+			t.Errorf("boundary %v, expected 0.395", boundary)
+		}
+	})
+
+	t.Run("non-separable", func(t *testing.T) {
+		// The proportion of relevant samples at each distance follows a known
+		// logistic curve, which the fit should recover.
+		const intercept, slope = 2.0, -4.0
+
+		var (
+			features [][]float64
+			labels   []bool
+		)
+
+		for i := range 11 {
+			distance := 0.1 * float64(i)
+			relevant := int(math.Round(100 * sigmoid(intercept+slope*distance)))
+
+			for j := range 100 {
+				features = append(features, []float64{distance})
+				labels = append(labels, j < relevant)
+			}
+		}
+
+		beta, err := fitLogistic(features, labels, 1e-6)
+		if err != nil {
+			t.Fatalf("fitting: %v", err)
+		}
+
+		if math.Abs(beta[0]-intercept) > 0.05 || math.Abs(beta[1]-slope) > 0.05 {
+			t.Errorf("got intercept %v and slope %v, expected %v and %v", beta[0], beta[1], intercept, slope)
+		}
+	})
+}
+
+func TestTrainRanker(t *testing.T) {
+	var samples []RankerSample
+
+	for i := range 40 {
+		distance := 0.2 + 0.01*float64(i)
+
+		samples = append(samples, RankerSample{
+			Distances: map[Model]float64{ModelAppleNLContextualEmbedding: distance},
+			// Mostly, but not always, the closer results are relevant.
+			Relevant: distance < 0.4 != (i%7 == 0),
+		})
+	}
+
+	ranker, err := TrainRanker(samples)
+	if err != nil {
+		t.Fatalf("training: %v", err)
+	}
+
+	if len(ranker.Models) != 1 || ranker.Models[0].Model != ModelAppleNLContextualEmbedding {
+		t.Fatalf("expected a calibration for one model, got %+v", ranker.Models)
+	}
+
+	near := ranker.Score(map[Model]float64{ModelAppleNLContextualEmbedding: 0.2})
+	far := ranker.Score(map[Model]float64{ModelAppleNLContextualEmbedding: 0.6})
+
+	if near <= far {
+		t.Errorf("near result scored %v, not above the far result's %v", near, far)
+	}
+
+	if _, err := TrainRanker(samples[1:7]); err == nil {
+		t.Error("expected training on only relevant feedback to fail")
+	}
+}
+
+func TestRankerRejection(t *testing.T) {
+	active := 0.8
+
+	tests := []struct {
+		name      string
+		auc       float64
+		activeAUC *float64
+		rejection string
+	}{
+		{
+			name: "improves",
+			auc:  0.75,
+		},
+		{
+			name:      "doesn't improve on distance",
+			auc:       0.71,
+			rejection: "doesn't improve on distance alone",
+		},
+		{
+			name:      "worse than the active ranker",
+			auc:       0.75,
+			activeAUC: &active,
+			rejection: "worse than the active ranker's",
+		},
+		{
+			name:      "better than the active ranker",
+			auc:       0.85,
+			activeAUC: &active,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranker := &Ranker{AUC: tt.auc, BaselineAUC: 0.7}
+
+			rejection := ranker.Rejection(0.02, tt.activeAUC)
+
+			switch {
+			case tt.rejection == "":
+				if rejection != "" {
+					t.Errorf("expected the ranker to be activated, got %q", rejection)
+				}
+			case !strings.Contains(rejection, tt.rejection):
+				t.Errorf("expected a rejection containing %q, got %q", tt.rejection, rejection)
+			}
+		})
+	}
+}
+
+func TestBaselineScore(t *testing.T) {
+	// Distances of at least 1 must still be ordered, rather than all scoring
+	// zero.
+	near := RankerSample{Distances: map[Model]float64{
+		ModelAppleNLContextualEmbedding: 1.2,
+		ModelOpenAITextEmbedding3Large:  1.1,
+	}}
+	far := RankerSample{Distances: map[Model]float64{ModelAppleNLContextualEmbedding: 1.5}}
+
+	if BaselineScore(near) <= BaselineScore(far) {
+		t.Errorf("near sample scored %v, not above the far sample's %v", BaselineScore(near), BaselineScore(far))
+	}
+
+	if score := BaselineScore(near); math.Abs(score-(1-1.1)) > 1e-9 {
+		t.Errorf("got score %v, expected the closest distance's %v", score, 1-1.1)
+	}
+}
+
+func TestRankerApply(t *testing.T) {
+	ranker := &Ranker{
+		Models: []ModelCalibration{
+			{Model: ModelAppleNLContextualEmbedding, Intercept: 2, Slope: -4, Weight: 1},
+			{Model: ModelOpenAITextEmbedding3Large, Intercept: 2, Slope: -4, Weight: 2},
+		},
+	}
+
+	results := map[Model][]SimilarDefinition{
+		ModelAppleNLContextualEmbedding: {
+			{ID: 1, Distance: 0.2},
+			{ID: 2, Distance: 0.3},
+		},
+		ModelOpenAITextEmbedding3Large: {
+			{ID: 2, Distance: 0.1},
+			{ID: 3, Distance: 0.9},
+		},
+		// Models without a calibration are ignored.
+		ModelQwen3Embedding8B4B_DWQ: {
+			{ID: 4, Distance: 0.1},
+		},
+	}
+
+	fused := ranker.Apply(results, 2)
+
+	// Word 2 was returned by both models, so is the most likely to be relevant.
+	if len(fused) != 2 || fused[0].ID != 2 || fused[1].ID != 1 {
+		t.Fatalf("got fused results %+v, expected words 2 and 1", fused)
+	}
+
+	// The fused result is the OpenAI result, which has the higher probability.
+	if fused[0].Distance != 0.1 {
+		t.Errorf("fused word 2 has distance %v, expected the closer OpenAI result's", fused[0].Distance)
+	}
+
+	expected := ranker.Score(map[Model]float64{
+		ModelAppleNLContextualEmbedding: 0.3,
+		ModelOpenAITextEmbedding3Large:  0.1,
+	})
+
+	if fused[0].Score != expected {
+		t.Errorf("fused word 2 scored %v, expected %v", fused[0].Score, expected)
+	}
+
+	// Each model's results keep their own calibrated probabilities.
+	calibrated := results[ModelOpenAITextEmbedding3Large][0]
+
+	if probability := ranker.Models[1].Probability(0.1); calibrated.Score != probability {
+		t.Errorf("model result scored %v, expected its probability %v", calibrated.Score, probability)
+	}
+
+	if results[ModelQwen3Embedding8B4B_DWQ][0].Score != 0 {
+		t.Error("expected the uncalibrated model's results to be left unscored")
+	}
+}
@@ -0,0 +1,192 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitSweepInterval is how often idle clients are evicted from a
+// [RateLimiter].
+const rateLimitSweepInterval = 5 * time.Minute
+
+// EndUserHeader identifies the user a request was made on behalf of, for
+// requests authenticated with a [ScopeProxy] key. It is ignored otherwise.
+const EndUserHeader = "X-Forwarded-For"
+
+// RateLimiter is a per-client token bucket rate limiter.
+//
+// Clients are identified by their API key where a valid one is provided,
+// falling back to their IP address otherwise. Unrecognised keys are ignored, so
+// that made-up keys can't be used to get a fresh bucket for every request.
+// Requests with a [ScopeProxy] key are further identified by the user they
+// were made on behalf of, so that a frontend's users don't share a bucket.
+type RateLimiter struct {
+	limit  rate.Limit
+	burst  int
+	lookup APIKeyLookup
+
+	mu        sync.Mutex
+	clients   map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+// APIKeyLookup finds the active API key matching a key string, returning
+// [ErrAPIKeyNotFound] if there is none (e.g. [SQLiteVec.LookupAPIKey]).
+type APIKeyLookup func(ctx context.Context, key string) (*APIKey, error)
+
+// NewRateLimiter creates a [RateLimiter] that refills each client's bucket at
+// `perSecond` requests per second, up to a maximum of `burst` requests. API
+// keys are validated with `lookup`.
+func NewRateLimiter(perSecond float64, burst int, lookup APIKeyLookup) *RateLimiter {
+	return &RateLimiter{
+		limit:     rate.Limit(perSecond),
+		burst:     burst,
+		lookup:    lookup,
+		clients:   make(map[string]*rate.Limiter),
+		lastSweep: time.Now(),
+	}
+}
+
+// Middleware returns an HTTP middleware that enforces the rate limit, and
+// reports the client's remaining allowance in the `RateLimit-*` headers.
+//
+// Rejected requests receive a 429 response in huma's error format. The API key
+// validated to identify the client is passed on in the request context, so
+// that it needn't be looked up again to authenticate the request.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, apiKey := l.clientKey(r)
+		if apiKey != nil {
+			r = r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, apiKey))
+		}
+
+		now := time.Now()
+		limiter := l.limiter(key, now)
+		allowed := limiter.AllowN(now, 1)
+		tokens := limiter.TokensAt(now)
+
+		// The reset is the time until the bucket is full again, and the policy
+		// window is the time taken to refill an empty bucket.
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(l.burst))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(max(0, int(math.Floor(tokens)))))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(l.refillSeconds(float64(l.burst)-tokens)))
+		w.Header().Set(
+			"RateLimit-Policy",
+			fmt.Sprintf("%d;w=%d", l.burst, l.refillSeconds(float64(l.burst))),
+		)
+
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(l.refillSeconds(1-tokens)))
+
+			writeHumaError(w, r, huma.Error429TooManyRequests("rate limit exceeded"))
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limiter returns the token bucket for the given client, creating it if
+// required.
+func (l *RateLimiter) limiter(key string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > rateLimitSweepInterval {
+		l.sweep(now)
+	}
+
+	limiter, ok := l.clients[key]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.clients[key] = limiter
+	}
+
+	return limiter
+}
+
+// sweep forgets clients whose buckets have fully refilled, as they are
+// indistinguishable from new clients.
+//
+// Must be called with the lock held.
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, limiter := range l.clients {
+		if limiter.TokensAt(now) >= float64(l.burst) {
+			delete(l.clients, key)
+		}
+	}
+
+	l.lastSweep = now
+}
+
+// clientKey identifies the client making the request, by the ID of its API
+// key if it provided a valid one (and the end user, for proxy keys), and
+// otherwise by its IP address. The validated API key is also returned, if
+// any.
+func (l *RateLimiter) clientKey(r *http.Request) (string, *APIKey) {
+	if key := requestAPIKey(r.Header.Get); key != "" {
+		apiKey, err := l.lookup(r.Context(), key)
+		if err == nil {
+			client := "key:" + strconv.FormatInt(apiKey.ID, 10)
+
+			// Proxies further along the chain append to the header, so the
+			// first address is the one the frontend set.
+			user, _, _ := strings.Cut(r.Header.Get(EndUserHeader), ",")
+			if user = strings.TrimSpace(user); user != "" && apiKey.HasScope(ScopeProxy) {
+				client += ":user:" + user
+			}
+
+			return client, apiKey
+		}
+
+		if !errors.Is(err, ErrAPIKeyNotFound) {
+			slog.WarnContext(
+				r.Context(),
+				"looking up api key to rate limit",
+				slog.Any("error", err),
+			)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host, nil
+}
+
+// refillSeconds returns the number of whole seconds taken to refill the given
+// number of tokens.
+func (l *RateLimiter) refillSeconds(tokens float64) int {
+	return int(math.Ceil(max(0, tokens) / float64(l.limit)))
+}
+
+// writeHumaError writes an error outside of a huma handler, in the same
+// problem+json format that huma uses.
+func writeHumaError(w http.ResponseWriter, r *http.Request, err huma.StatusError) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(err.GetStatus())
+
+	if encodeErr := json.NewEncoder(w).Encode(err); encodeErr != nil {
+		slog.ErrorContext(
+			r.Context(),
+			"writing error response",
+			slog.Any("error", encodeErr),
+		)
+	}
+}
@@ -0,0 +1,63 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimiterEndUsers(t *testing.T) {
+	keys := map[string]*APIKey{
+		"frontend": {ID: 1, Scopes: []Scope{ScopeSearch, ScopeProxy}},
+		"client":   {ID: 2, Scopes: []Scope{ScopeSearch}},
+	}
+
+	lookup := func(_ context.Context, key string) (*APIKey, error) {
+		apiKey, ok := keys[key]
+		if !ok {
+			return nil, ErrAPIKeyNotFound
+		}
+
+		return apiKey, nil
+	}
+
+	// A burst of one, so that a client's second request is rejected.
+	handler := NewRateLimiter(0.001, 1, lookup).Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+
+	request := func(key string, user string) int {
+		r := httptest.NewRequest(http.MethodGet, "/api/search", nil)
+		r.Header.Set("Authorization", "Bearer "+key)
+
+		if user != "" {
+			r.Header.Set(EndUserHeader, user)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		user   string
+		status int
+	}{
+		{"first user of the frontend", "frontend", "192.0.2.1", http.StatusOK},
+		{"second user of the frontend", "frontend", "192.0.2.2", http.StatusOK},
+		{"first user again", "frontend", "192.0.2.1, 10.0.0.1", http.StatusTooManyRequests},
+		{"client", "client", "192.0.2.3", http.StatusOK},
+		// Only proxy keys may name the user.
+		{"client claiming another user", "client", "192.0.2.4", http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		if status := request(tt.key, tt.user); status != tt.status {
+			t.Errorf("%s: got status %d, expected %d", tt.name, status, tt.status)
+		}
+	}
+}
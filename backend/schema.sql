@@ -5,9 +5,13 @@ CREATE TABLE words (
     definition TEXT NOT NULL,
     example TEXT NOT NULL,
     author TEXT NOT NULL,
+    content_rating TEXT NOT NULL DEFAULT 'unrated' CHECK (content_rating IN ('unrated', 'safe', 'explicit', 'offensive')),
     UNIQUE (word, definition)
 ) STRICT;
 
+-- Used by `LIKE` prefix matches, which are case-insensitive.
+CREATE INDEX words_word_nocase ON words (word COLLATE NOCASE);
+
 CREATE TABLE word_features (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     word_id INTEGER NOT NULL,
@@ -20,6 +24,9 @@ CREATE TABLE word_features (
 CREATE TABLE embedding_models (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL,
+    dimensions INTEGER NOT NULL,
+    quantization TEXT NOT NULL DEFAULT 'none' CHECK (quantization IN ('none', 'int8', 'bit')),
+    full_precision INTEGER NOT NULL DEFAULT 1 CHECK (full_precision IN (0, 1)),
     UNIQUE (name)
 ) STRICT;
 
@@ -32,9 +39,120 @@ CREATE TABLE embeddings (
     FOREIGN KEY (embedding_model_id) REFERENCES embedding_models (id)
 ) STRICT;
 
+CREATE TABLE quantized_embeddings (
+    word_feature_id INTEGER NOT NULL,
+    embedding_model_id INTEGER NOT NULL,
+    embedding BLOB NOT NULL,
+    PRIMARY KEY (word_feature_id, embedding_model_id),
+    FOREIGN KEY (word_feature_id) REFERENCES word_features (id),
+    FOREIGN KEY (embedding_model_id) REFERENCES embedding_models (id)
+) STRICT;
+
+CREATE TABLE eval_queries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    query TEXT NOT NULL,
+    word_id INTEGER NOT NULL,
+    created_at INTEGER NOT NULL DEFAULT (unixepoch()),
+    UNIQUE (query, word_id),
+    FOREIGN KEY (word_id) REFERENCES words (id)
+) STRICT;
+
+CREATE TRIGGER embeddings_dimensions_insert BEFORE INSERT ON embeddings
+WHEN vec_length(NEW.embedding) != (
+    SELECT dimensions FROM embedding_models WHERE id = NEW.embedding_model_id
+)
+BEGIN
+    SELECT RAISE(ABORT, 'embedding dimensions do not match the model');
+END;
+
+CREATE TRIGGER embeddings_dimensions_update BEFORE UPDATE OF embedding ON embeddings
+WHEN vec_length(NEW.embedding) != (
+    SELECT dimensions FROM embedding_models WHERE id = NEW.embedding_model_id
+)
+BEGIN
+    SELECT RAISE(ABORT, 'embedding dimensions do not match the model');
+END;
+
+CREATE TABLE api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    key_hash BLOB NOT NULL,
+    scopes TEXT NOT NULL,
+    created_at INTEGER NOT NULL DEFAULT (unixepoch()),
+    revoked_at INTEGER,
+    UNIQUE (key_hash)
+) STRICT;
+
+CREATE TABLE api_key_usage (
+    api_key_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    last_used_at INTEGER NOT NULL,
+    PRIMARY KEY (api_key_id, day),
+    FOREIGN KEY (api_key_id) REFERENCES api_keys (id)
+) STRICT;
+
+CREATE TABLE feedback (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    query TEXT NOT NULL,
+    embedding_model_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    rank INTEGER NOT NULL CHECK (rank >= 1),
+    verdict TEXT NOT NULL CHECK (verdict IN ('relevant', 'irrelevant', 'meant')),
+    created_at INTEGER NOT NULL DEFAULT (unixepoch()),
+    FOREIGN KEY (embedding_model_id) REFERENCES embedding_models (id),
+    FOREIGN KEY (word_id) REFERENCES words (id)
+) STRICT;
+
+CREATE TABLE rankers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bias REAL NOT NULL,
+    samples INTEGER NOT NULL,
+    auc REAL NOT NULL,
+    baseline_auc REAL NOT NULL,
+    active INTEGER NOT NULL DEFAULT 0 CHECK (active IN (0, 1)),
+    created_at INTEGER NOT NULL DEFAULT (unixepoch())
+) STRICT;
+
+CREATE UNIQUE INDEX rankers_active ON rankers (active) WHERE active = 1;
+
+CREATE TABLE ranker_models (
+    ranker_id INTEGER NOT NULL,
+    embedding_model_id INTEGER NOT NULL,
+    intercept REAL NOT NULL,
+    slope REAL NOT NULL,
+    weight REAL NOT NULL,
+    PRIMARY KEY (ranker_id, embedding_model_id),
+    FOREIGN KEY (ranker_id) REFERENCES rankers (id),
+    FOREIGN KEY (embedding_model_id) REFERENCES embedding_models (id)
+) STRICT;
+
+CREATE TABLE query_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    query TEXT,
+    query_hash BLOB NOT NULL,
+    safe INTEGER NOT NULL,
+    zero_results INTEGER NOT NULL,
+    created_at INTEGER NOT NULL DEFAULT (unixepoch())
+) STRICT;
+
+CREATE INDEX query_log_created_at ON query_log (created_at);
+
+CREATE TABLE query_log_models (
+    query_log_id INTEGER NOT NULL,
+    embedding_model_id INTEGER NOT NULL,
+    results INTEGER NOT NULL,
+    latency_ms REAL NOT NULL,
+    top_word_id INTEGER,
+    PRIMARY KEY (query_log_id, embedding_model_id),
+    FOREIGN KEY (query_log_id) REFERENCES query_log (id),
+    FOREIGN KEY (embedding_model_id) REFERENCES embedding_models (id),
+    FOREIGN KEY (top_word_id) REFERENCES words (id)
+) STRICT;
+
 INSERT
-    OR REPLACE INTO embedding_models (id, name)
+    OR REPLACE INTO embedding_models (id, name, dimensions)
 VALUES
-    (1, 'mlx-community/Qwen3-Embedding-8B-4bit-DWQ'),
-    (2, 'apple/nlcontextualembedding'),
-    (3, 'openai/text-embedding-3-large');
+    (1, 'mlx-community/Qwen3-Embedding-8B-4bit-DWQ', 4096),
+    (2, 'apple/nlcontextualembedding', 512),
+    (3, 'openai/text-embedding-3-large', 3072);
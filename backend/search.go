@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SearchMode selects how the closest words to a query are found.
+type SearchMode string
+
+const (
+	// SearchModeAuto searches the quantized embeddings of models which have
+	// them (rescoring the candidates at full precision, where kept), and the
+	// full-precision embeddings otherwise.
+	SearchModeAuto SearchMode = "auto"
+	// SearchModeExact scans the full-precision embeddings, unless they were
+	// dropped (see [SQLiteVec.DropFullPrecision]).
+	SearchModeExact SearchMode = "exact"
+)
+
+// SearchModes lists all the supported search modes.
+var SearchModes = []SearchMode{
+	SearchModeAuto,
+	SearchModeExact,
+}
+
+func SearchModeFromString(s string) (SearchMode, error) {
+	for _, mode := range SearchModes {
+		if string(mode) == s {
+			return mode, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown search mode: %s", s)
+}
+
+// Searcher finds the words matching a description, using each of the
+// configured embedding models. It is the core of [API.Search], shared with the
+// offline tools so that they measure exactly what the API serves.
+type Searcher struct {
+	embedders Embedders
+	sqliteVec *SQLiteVec
+}
+
+// NewSearcher creates a [Searcher] embedding queries with the given
+// embedders.
+func NewSearcher(embedders Embedders, sqliteVec *SQLiteVec) *Searcher {
+	return &Searcher{
+		embedders: embedders,
+		sqliteVec: sqliteVec,
+	}
+}
+
+// Search returns up to `limit` matching words for each model. If `safe` is
+// set, only words rated [ContentRatingSafe] are returned.
+func (s *Searcher) Search(
+	ctx context.Context,
+	query string,
+	limit int,
+	mode SearchMode,
+	safe bool,
+) (map[Model][]SimilarDefinition, error) {
+	results, _, err := s.SearchTimed(ctx, query, limit, mode, safe)
+
+	return results, err
+}
+
+// SearchTimed is [Searcher.Search], also returning how long each model took
+// to embed the query and search for it.
+func (s *Searcher) SearchTimed(
+	ctx context.Context,
+	query string,
+	limit int,
+	mode SearchMode,
+	safe bool,
+) (_ map[Model][]SimilarDefinition, _ map[Model]time.Duration, err error) {
+	ctx, span := tracer.Start(
+		ctx,
+		"Searcher.Search",
+		trace.WithAttributes(
+			attribute.Int("query_length", len(query)),
+			attribute.Int("limit", limit),
+			attribute.String("mode", string(mode)),
+			attribute.Bool("safe", safe),
+		),
+	)
+	defer func() { endSpan(span, err) }()
+
+	results := make(map[Model][]SimilarDefinition, len(s.embedders))
+	latencies := make(map[Model]time.Duration, len(s.embedders))
+
+	for model, embedder := range s.embedders {
+		start := time.Now()
+
+		// Embedding with each model separately times each model separately.
+		queryEmbeddings, err := (&Embedders{model: embedder}).Embed(ctx, query)
+		if err != nil {
+			return nil, nil, fmt.Errorf("embedding query: %w", err)
+		}
+
+		if len(queryEmbeddings[model]) == 0 {
+			return nil, nil, fmt.Errorf("no embeddings returned for query: %s", query)
+		}
+
+		quantization, err := s.sqliteVec.ModelQuantization(model)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if mode == SearchModeExact && s.sqliteVec.KeepsFullPrecision(model) {
+			quantization = QuantizationNone
+		}
+
+		modelResults, err := s.sqliteVec.relatedWords(
+			ctx,
+			model,
+			queryEmbeddings[model][0],
+			limit,
+			quantization,
+			safe,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("searching in SQLiteVec: %w", err)
+		}
+
+		results[model] = modelResults
+		latencies[model] = time.Since(start)
+	}
+
+	return results, latencies, nil
+}
@@ -0,0 +1,101 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Server timeouts. The write timeout is generous, as searches wait on the
+// embedding models, which can be slow to respond under load.
+const (
+	ServerReadHeaderTimeout = 5 * time.Second
+	ServerReadTimeout       = 15 * time.Second
+	ServerWriteTimeout      = 60 * time.Second
+	ServerIdleTimeout       = 120 * time.Second
+
+	// DefaultShutdownTimeout is how long in-flight requests are given to
+	// complete once shutdown starts.
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+// NewHTTPServer creates an [http.Server] with the standard timeouts.
+func NewHTTPServer(address string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: ServerReadHeaderTimeout,
+		ReadTimeout:       ServerReadTimeout,
+		WriteTimeout:      ServerWriteTimeout,
+		IdleTimeout:       ServerIdleTimeout,
+	}
+}
+
+// ListenAndServe runs the server until the context is cancelled, then shuts it
+// down gracefully: no new connections are accepted, and in-flight requests are
+// given up to `shutdownTimeout` to complete.
+//
+// Requests run under a context which is only cancelled once the drain is over
+// (or has timed out), so that any outstanding calls (e.g. to the embedders)
+// are abandoned rather than left running after the server stops.
+func ListenAndServe(
+	ctx context.Context,
+	server *http.Server,
+	shutdownTimeout time.Duration,
+) error {
+	// Keep the context's values (e.g. the logger), but not its cancellation,
+	// which would cancel every in-flight request as soon as shutdown starts.
+	baseCtx, cancelRequests := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelRequests()
+
+	server.BaseContext = func(net.Listener) context.Context {
+		return baseCtx
+	}
+
+	serveErr := make(chan error, 1)
+
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("serving HTTP: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.InfoContext(
+		ctx,
+		"shutting down server",
+		slog.Duration("timeout", shutdownTimeout),
+	)
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.WarnContext(
+			ctx,
+			"in-flight requests did not complete before the shutdown timeout",
+			slog.Any("error", err),
+		)
+
+		cancelRequests()
+
+		if err := server.Close(); err != nil {
+			return fmt.Errorf("closing server: %w", err)
+		}
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving HTTP: %w", err)
+	}
+
+	slog.InfoContext(ctx, "server stopped")
+
+	return nil
+}
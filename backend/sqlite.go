@@ -7,13 +7,23 @@ import (
 	"errors"
 	"fmt"
 	"iter"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// ErrWordNotFound is returned when a word does not exist.
+var ErrWordNotFound = errors.New("word not found")
+
 type SQLiteVec struct {
 	db *sql.DB
+
+	// models holds the metadata of each embedding model, e.g. the expected
+	// number of dimensions of its embeddings.
+	models map[Model]modelMetadata
 }
 
 func NewSQLiteVec(ctx context.Context, dbPath string) (*SQLiteVec, error) {
@@ -26,9 +36,23 @@ func NewSQLiteVec(ctx context.Context, dbPath string) (*SQLiteVec, error) {
 		return nil, fmt.Errorf("opening sqlite database: %w", err)
 	}
 
-	return &SQLiteVec{
+	sqliteVec := &SQLiteVec{
 		db: db,
-	}, nil
+	}
+
+	if err := sqliteVec.migrate(ctx); err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("migrating sqlite database: %w", err)
+	}
+
+	if err := sqliteVec.loadModelMetadata(ctx); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	return sqliteVec, nil
 }
 
 func (s *SQLiteVec) Close() error {
@@ -39,139 +63,354 @@ func (s *SQLiteVec) Close() error {
 	return nil
 }
 
+// querier is implemented by both [sql.DB] and [sql.Tx], so that writes can be
+// composed within a single transaction.
+type querier interface {
+	queryRower
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// inTx runs `f` within a transaction, which is committed if `f` succeeds and
+// rolled back otherwise.
+func (s *SQLiteVec) inTx(ctx context.Context, f func(tx *sql.Tx) error) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
 // AddDefinition adds a new definition and associated features to the database.
 //
 // * first we look up if the word already exists,
 // * otherwise the word is added to the words table;
 // * then its features are added to the features table;
 // * then the embeddings for each feature are added to the embeddings table.
+//
+// This all happens in a single transaction, so either the whole definition is
+// added, or nothing is.
 func (s *SQLiteVec) AddDefinition(
 	ctx context.Context,
 	definition Definition,
 ) (_ int64, err error) {
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return 0, fmt.Errorf("beginning transaction: %w", err)
-	}
+	ctx, span := startSQLiteSpan(ctx, "AddDefinition")
+	defer func() { endSpan(span, err) }()
 
-	defer func() {
+	var wordID int64
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+
+		wordID, err = addWord(ctx, tx, definition.Word)
 		if err != nil {
-			tx.Rollback()
+			return err
 		}
-	}()
 
+		if err := s.addFeatures(ctx, tx, wordID, definition.Features); err != nil {
+			return fmt.Errorf("adding features: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return wordID, nil
+}
+
+// addWord returns the ID of the word, inserting it if it doesn't exist yet.
+func addWord(ctx context.Context, q querier, word Word) (int64, error) {
 	var wordID int64
 
-	if s.db.QueryRowContext(
+	if err := q.QueryRowContext(
 		ctx,
 		`
 			SELECT id
 			FROM words
 			WHERE word = ? AND definition = ?
 		`,
-		definition.Word,
-		definition.Definition,
-	).Scan(&wordID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		word.Word,
+		word.Definition,
+	).Scan(&wordID); err == nil {
+		return wordID, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
 		return 0, fmt.Errorf("querying word: %w", err)
 	}
 
-	if wordID == 0 {
-		if err := s.db.QueryRowContext(
-			ctx,
-			`
-				INSERT INTO words (word, definition, example, author)
-				VALUES (?, ?, ?, ?)
-				RETURNING id
-			`,
-			definition.Word,
-			definition.Definition,
-			definition.Example,
-			definition.Author,
-		).Scan(&wordID); err != nil {
-			return 0, fmt.Errorf("inserting new word details: %w", err)
-		}
+	rating := word.ContentRating
+	if rating == "" {
+		rating = ContentRatingUnrated
 	}
 
-	s.AddFeatures(ctx, wordID, definition.Features)
-
-	if err := tx.Commit(); err != nil {
-		return 0, fmt.Errorf("committing transaction: %w", err)
+	if err := q.QueryRowContext(
+		ctx,
+		`
+			INSERT INTO words (word, definition, example, author, content_rating)
+			VALUES (?, ?, ?, ?, ?)
+			RETURNING id
+		`,
+		word.Word,
+		word.Definition,
+		word.Example,
+		word.Author,
+		rating,
+	).Scan(&wordID); err != nil {
+		return 0, fmt.Errorf("inserting new word details: %w", err)
 	}
 
 	return wordID, nil
 }
 
+// RelatedWords returns the words whose features are closest to the embedding.
+//
+// If the model's embeddings are quantized, the closest features are first
+// found using the quantized embeddings, then rescored using the
+// full-precision embeddings. If the full-precision embeddings were dropped,
+// the results are ranked on the quantized distances alone.
+//
+// If `safe` is set, only words rated [ContentRatingSafe] are returned.
 func (s *SQLiteVec) RelatedWords(
 	ctx context.Context,
 	model Model,
 	vector Embedding,
 	limit int,
+	safe bool,
 ) ([]SimilarDefinition, error) {
+	quantization, err := s.ModelQuantization(model)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.relatedWords(ctx, model, vector, limit, quantization, safe)
+}
+
+// relatedWords is [SQLiteVec.RelatedWords], searching the embeddings with the
+// given quantization rather than the model's.
+func (s *SQLiteVec) relatedWords(
+	ctx context.Context,
+	model Model,
+	vector Embedding,
+	limit int,
+	quantization Quantization,
+	safe bool,
+) (_ []SimilarDefinition, err error) {
+	ctx, span := startSQLiteSpan(
+		ctx,
+		"RelatedWords",
+		attribute.String("model", model.String()),
+		attribute.String("quantization", string(quantization)),
+		attribute.Int("limit", limit),
+		attribute.Bool("safe", safe),
+	)
+	defer func() { endSpan(span, err) }()
+
+	if err := s.checkDimensions(model, vector); err != nil {
+		return nil, err
+	}
+
 	vec, err := sqlite_vec.SerializeFloat32(vector)
 	if err != nil {
 		return nil, fmt.Errorf("serializing embedding: %w", err)
 	}
 
-	stmt, err := s.db.PrepareContext(
-		ctx,
-		`
+	start := time.Now()
+
+	defer func() {
+		relatedWordsDuration.WithLabelValues(model.String()).Observe(time.Since(start).Seconds())
+	}()
+
+	var (
+		query string
+		args  []any
+	)
+
+	// The bare `phrase` column takes its value from the row with the minimum
+	// distance, so is the phrase of the best-matching feature.
+	switch {
+	case quantization == QuantizationNone:
+		query = `
 		WITH best AS (
 			SELECT
 				wf.word_id,
+				wf.phrase,
 				MIN(vec_distance_cosine(e.embedding, ?)) AS distance
 			FROM word_features wf
+			JOIN words w ON w.id = wf.word_id
 			JOIN embeddings e ON e.word_feature_id = wf.id
 			WHERE e.embedding_model_id = ?
+				AND (? = 0 OR w.content_rating = 'safe')
 			GROUP BY wf.word_id
 		)
-		SELECT w.word, w.definition, w.example, w.author, best.distance, ''
+		SELECT w.id, w.word, w.definition, w.example, w.author, w.content_rating, best.distance, best.phrase
 		FROM words w
 		JOIN best ON w.id = best.word_id
 		ORDER BY best.distance ASC
 		LIMIT ?
-		`,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("preparing statement: %w", err)
+		`
+		args = []any{vec, model, safe, limit}
+	case !s.KeepsFullPrecision(model):
+		query = fmt.Sprintf(
+			`
+			WITH best AS (
+				SELECT
+					wf.word_id,
+					wf.phrase,
+					MIN(%s) AS distance
+				FROM word_features wf
+				JOIN words w ON w.id = wf.word_id
+				JOIN quantized_embeddings qe ON qe.word_feature_id = wf.id
+				WHERE qe.embedding_model_id = ?
+					AND (? = 0 OR w.content_rating = 'safe')
+				GROUP BY wf.word_id
+			)
+			SELECT w.id, w.word, w.definition, w.example, w.author, w.content_rating, best.distance, best.phrase
+			FROM words w
+			JOIN best ON w.id = best.word_id
+			ORDER BY best.distance ASC
+			LIMIT ?
+			`,
+			quantization.distance("qe.embedding", "?"),
+		)
+		args = []any{vec, model, safe, limit}
+	default:
+		query = fmt.Sprintf(
+			`
+			WITH candidates AS (
+				SELECT qe.word_feature_id
+				FROM quantized_embeddings qe
+				JOIN word_features wf ON wf.id = qe.word_feature_id
+				JOIN words w ON w.id = wf.word_id
+				WHERE qe.embedding_model_id = ?
+					AND (? = 0 OR w.content_rating = 'safe')
+				ORDER BY %s
+				LIMIT ?
+			),
+			best AS (
+				SELECT
+					wf.word_id,
+					wf.phrase,
+					MIN(vec_distance_cosine(e.embedding, ?)) AS distance
+				FROM candidates c
+				JOIN word_features wf ON wf.id = c.word_feature_id
+				JOIN embeddings e ON e.word_feature_id = c.word_feature_id
+				WHERE e.embedding_model_id = ?
+				GROUP BY wf.word_id
+			)
+			SELECT w.id, w.word, w.definition, w.example, w.author, w.content_rating, best.distance, best.phrase
+			FROM words w
+			JOIN best ON w.id = best.word_id
+			ORDER BY best.distance ASC
+			LIMIT ?
+			`,
+			quantization.distance("qe.embedding", "?"),
+		)
+		args = []any{
+			model,
+			safe,
+			vec,
+			limit * quantizedCandidatesPerResult,
+			vec,
+			model,
+			limit,
+		}
 	}
 
-	defer stmt.Close()
-
-	rows, err := stmt.QueryContext(ctx, vec, model, limit)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("querying statement: %w", err)
+		return nil, fmt.Errorf("querying related words: %w", err)
 	}
 
+	defer rows.Close()
+
 	var definitions []SimilarDefinition
 
 	for rows.Next() {
 		var definition SimilarDefinition
 		if err := rows.Scan(
+			&definition.ID,
 			&definition.Word.Word,
 			&definition.Word.Definition,
 			&definition.Word.Example,
 			&definition.Word.Author,
+			&definition.Word.ContentRating,
 			&definition.Distance,
 			&definition.Phrase,
 		); err != nil {
 			return nil, fmt.Errorf("scanning row: %w", err)
 		}
 
+		if !s.KeepsFullPrecision(model) {
+			definition.Distance = quantization.cosineDistance(definition.Distance, s.models[model].dimensions)
+		}
+
 		definitions = append(definitions, definition)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
 	return definitions, nil
 }
 
+// AddFeatures adds features, and their embeddings, to an existing word in a
+// single transaction.
 func (s *SQLiteVec) AddFeatures(
 	ctx context.Context,
 	wordID int64,
 	features []Feature,
+) (err error) {
+	ctx, span := startSQLiteSpan(
+		ctx,
+		"AddFeatures",
+		attribute.Int64("word_id", wordID),
+		attribute.Int("features", len(features)),
+	)
+	defer func() { endSpan(span, err) }()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		return s.addFeatures(ctx, tx, wordID, features)
+	})
+}
+
+// addFeatures adds the features of a word, and their embeddings, updating the
+// embeddings of any features which already exist.
+func (s *SQLiteVec) addFeatures(
+	ctx context.Context,
+	q querier,
+	wordID int64,
+	features []Feature,
 ) error {
+	// Validate everything up front, rather than failing part way through.
+	for _, feature := range features {
+		for model, embedding := range feature.Embeddings {
+			if err := s.checkDimensions(model, embedding); err != nil {
+				return fmt.Errorf("validating feature %q: %w", feature.Phrase, err)
+			}
+		}
+	}
+
 	featureIDs := make([]int64, 0, len(features))
 
 	for _, feature := range features {
-		id, err := s.addFeature(ctx, wordID, feature)
+		id, err := addFeature(ctx, q, wordID, feature)
 		if err != nil {
 			return fmt.Errorf("adding feature: %w", err)
 		}
@@ -179,7 +418,7 @@ func (s *SQLiteVec) AddFeatures(
 		featureIDs = append(featureIDs, id)
 	}
 
-	embeddingInsertionStatement, err := s.db.PrepareContext(
+	embeddingInsertionStatement, err := q.PrepareContext(
 		ctx,
 		`
 			INSERT INTO embeddings (
@@ -204,13 +443,19 @@ func (s *SQLiteVec) AddFeatures(
 				return fmt.Errorf("serializing embedding: %w", err)
 			}
 
-			if _, err := embeddingInsertionStatement.ExecContext(
-				ctx,
-				featureIDs[i],
-				model,
-				embeddingBytes,
-			); err != nil {
-				return fmt.Errorf("inserting embedding: %w", err)
+			if s.KeepsFullPrecision(model) {
+				if _, err := embeddingInsertionStatement.ExecContext(
+					ctx,
+					featureIDs[i],
+					model,
+					embeddingBytes,
+				); err != nil {
+					return fmt.Errorf("inserting embedding: %w", err)
+				}
+			}
+
+			if err := s.addQuantizedEmbedding(ctx, q, featureIDs[i], model, embeddingBytes); err != nil {
+				return err
 			}
 		}
 	}
@@ -218,14 +463,15 @@ func (s *SQLiteVec) AddFeatures(
 	return nil
 }
 
-func (s *SQLiteVec) addFeature(
+func addFeature(
 	ctx context.Context,
+	q querier,
 	wordID int64,
 	feature Feature,
 ) (int64, error) {
 	var id int64
 
-	if err := s.db.QueryRowContext(
+	if err := q.QueryRowContext(
 		ctx,
 		`
 			SELECT id
@@ -241,7 +487,7 @@ func (s *SQLiteVec) addFeature(
 		return id, nil
 	}
 
-	if err := s.db.QueryRowContext(
+	if err := q.QueryRowContext(
 		ctx,
 		`
 		INSERT INTO word_features (word_id, phrase, autogenerated)
@@ -262,7 +508,14 @@ func (s *SQLiteVec) addFeature(
 func (s *SQLiteVec) GetWordFeatures(
 	ctx context.Context,
 	wordID int64,
-) ([]Feature, error) {
+) (_ []Feature, err error) {
+	ctx, span := startSQLiteSpan(
+		ctx,
+		"GetWordFeatures",
+		attribute.Int64("word_id", wordID),
+	)
+	defer func() { endSpan(span, err) }()
+
 	rows, err := s.db.QueryContext(
 		ctx,
 		`
@@ -335,11 +588,14 @@ func (s *SQLiteVec) GetWordFeatures(
 
 func (s *SQLiteVec) GetRandomDefinition(
 	ctx context.Context,
-) (*Word, error) {
+) (_ *DBWord, err error) {
+	ctx, span := startSQLiteSpan(ctx, "GetRandomDefinition")
+	defer func() { endSpan(span, err) }()
+
 	stmt, err := s.db.PrepareContext(
 		ctx,
 		`
-		SELECT word, definition, example, author
+		SELECT id, word, definition, example, author, content_rating
 		FROM words
 		ORDER BY RANDOM()
 		LIMIT 1
@@ -351,13 +607,15 @@ func (s *SQLiteVec) GetRandomDefinition(
 
 	defer stmt.Close()
 
-	var definition Word
+	var definition DBWord
 
 	if err := stmt.QueryRowContext(ctx).Scan(
-		&definition.Word,
+		&definition.ID,
+		&definition.Word.Word,
 		&definition.Definition,
 		&definition.Example,
 		&definition.Author,
+		&definition.ContentRating,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // No definitions found
@@ -383,7 +641,7 @@ func (s *SQLiteVec) GetWords(
 		stmt, err := s.db.PrepareContext(
 			ctx,
 			`
-		SELECT id, word, definition, example, author
+		SELECT id, word, definition, example, author, content_rating
 		FROM words
 		ORDER BY id
 		`,
@@ -414,6 +672,7 @@ func (s *SQLiteVec) GetWords(
 				&definition.Word.Definition,
 				&definition.Word.Example,
 				&definition.Word.Author,
+				&definition.Word.ContentRating,
 			); err != nil {
 				yield(nil, fmt.Errorf("scanning row: %w", err))
 
@@ -427,39 +686,121 @@ func (s *SQLiteVec) GetWords(
 	}
 }
 
-func (s *SQLiteVec) CompareEmbeddings(
-	ctx context.Context,
-	embedding1 Embedding,
-	embedding2 Embedding,
-) (float64, error) {
-	vec1, err := sqlite_vec.SerializeFloat32(embedding1)
-	if err != nil {
-		return 0, fmt.Errorf("serializing first embedding: %w", err)
+// GetWord returns the word with the given ID, or [ErrWordNotFound].
+func (s *SQLiteVec) GetWord(ctx context.Context, id int64) (_ *DBWord, err error) {
+	ctx, span := startSQLiteSpan(ctx, "GetWord", attribute.Int64("word_id", id))
+	defer func() { endSpan(span, err) }()
+
+	definition := DBWord{ID: id}
+
+	if err := s.db.QueryRowContext(
+		ctx,
+		`
+			SELECT word, definition, example, author, content_rating
+			FROM words
+			WHERE id = ?
+		`,
+		id,
+	).Scan(
+		&definition.Word.Word,
+		&definition.Word.Definition,
+		&definition.Word.Example,
+		&definition.Word.Author,
+		&definition.Word.ContentRating,
+	); errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWordNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("querying word: %w", err)
 	}
 
-	vec2, err := sqlite_vec.SerializeFloat32(embedding2)
+	return &definition, nil
+}
+
+// GetWordPhrases returns the features of a word, without their embeddings.
+func (s *SQLiteVec) GetWordPhrases(ctx context.Context, wordID int64) (_ []Feature, err error) {
+	ctx, span := startSQLiteSpan(ctx, "GetWordPhrases", attribute.Int64("word_id", wordID))
+	defer func() { endSpan(span, err) }()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+			SELECT phrase, autogenerated
+			FROM word_features
+			WHERE word_id = ?
+			ORDER BY id
+		`,
+		wordID,
+	)
 	if err != nil {
-		return 0, fmt.Errorf("serializing second embedding: %w", err)
+		return nil, fmt.Errorf("querying features: %w", err)
 	}
 
-	stmt, err := s.db.PrepareContext(
+	defer rows.Close()
+
+	var features []Feature
+
+	for rows.Next() {
+		var feature Feature
+
+		if err := rows.Scan(&feature.Phrase, &feature.Autogenerated); err != nil {
+			return nil, fmt.Errorf("scanning feature row: %w", err)
+		}
+
+		features = append(features, feature)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating features: %w", err)
+	}
+
+	return features, nil
+}
+
+// FindWords returns all the definitions of the word.
+func (s *SQLiteVec) FindWords(ctx context.Context, word string) (_ []DBWord, err error) {
+	ctx, span := startSQLiteSpan(ctx, "FindWords")
+	defer func() { endSpan(span, err) }()
+
+	rows, err := s.db.QueryContext(
 		ctx,
 		`
-		SELECT vec_distance_cosine(?, ?)
+			SELECT id, word, definition, example, author, content_rating
+			FROM words
+			WHERE word = ?
+			ORDER BY id
 		`,
+		word,
 	)
 	if err != nil {
-		return 0, fmt.Errorf("preparing statement: %w", err)
+		return nil, fmt.Errorf("querying words: %w", err)
 	}
 
-	defer stmt.Close()
+	defer rows.Close()
+
+	var words []DBWord
+
+	for rows.Next() {
+		var definition DBWord
+
+		if err := rows.Scan(
+			&definition.ID,
+			&definition.Word.Word,
+			&definition.Word.Definition,
+			&definition.Word.Example,
+			&definition.Word.Author,
+			&definition.Word.ContentRating,
+		); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		words = append(words, definition)
+	}
 
-	var distance float64
-	if err := stmt.QueryRowContext(ctx, vec1, vec2).Scan(&distance); err != nil {
-		return 0, fmt.Errorf("querying distance: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
 	}
 
-	return distance, nil
+	return words, nil
 }
 
 // jsonValue is a helper function to create a JSONScanner for a given value.
@@ -0,0 +1,150 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestSQLiteVec opens a new, migrated database in a temporary directory.
+func newTestSQLiteVec(t *testing.T) *SQLiteVec {
+	t.Helper()
+
+	s, err := NewSQLiteVec(context.Background(), filepath.Join(t.TempDir(), "words.db"))
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+// countRows returns the number of rows in the table.
+func countRows(t *testing.T, s *SQLiteVec, table string) int {
+	t.Helper()
+
+	var n int
+
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatalf("counting %s: %v", table, err)
+	}
+
+	return n
+}
+
+func TestAddDefinitionRollsBack(t *testing.T) {
+	const (
+		model      = ModelAppleNLContextualEmbedding
+		validModel = ModelOpenAITextEmbedding3Large
+	)
+
+	tests := []struct {
+		name string
+		// dimensions overrides the dimensions recorded for the model, so that
+		// embeddings of the wrong size pass validation and only fail when
+		// inserted.
+		dimensions int
+		embedding  Embedding
+		check      func(t *testing.T, err error)
+	}{
+		{
+			name:       "embedding insert fails",
+			dimensions: 8,
+			embedding:  make(Embedding, 8),
+			check: func(t *testing.T, err error) {
+				if !strings.Contains(err.Error(), "inserting embedding") {
+					t.Fatalf("expected the embedding insert to fail, got %v", err)
+				}
+			},
+		},
+		{
+			name:      "validation fails",
+			embedding: make(Embedding, 8),
+			check: func(t *testing.T, err error) {
+				var mismatch *DimensionMismatchError
+				if !errors.As(err, &mismatch) {
+					t.Fatalf("expected a DimensionMismatchError, got %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSQLiteVec(t)
+
+			if tt.dimensions != 0 {
+				metadata := s.models[model]
+				metadata.dimensions = tt.dimensions
+				s.models[model] = metadata
+			}
+
+			// The first feature is valid, so that its rows are written before
+			// the second feature fails.
+			dimensions, err := s.ModelDimensions(validModel)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			valid := make(Embedding, dimensions)
+			valid[0] = 1
+
+			_, err = s.AddDefinition(context.Background(), Definition{
+				Word: Word{
+					Word:       "smidge",
+					Definition: "a very small amount",
+				},
+				Features: []Feature{
+					{
+						Phrase:     "a very small amount",
+						Embeddings: map[Model]Embedding{validModel: valid},
+					},
+					{
+						Phrase:     "a tiny bit",
+						Embeddings: map[Model]Embedding{model: tt.embedding},
+					},
+				},
+			})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			tt.check(t, err)
+
+			for _, table := range []string{"words", "word_features", "embeddings"} {
+				if n := countRows(t, s, table); n != 0 {
+					t.Errorf("%d rows left in %s, expected none", n, table)
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownModelInDatabase(t *testing.T) {
+	s := newTestSQLiteVec(t)
+
+	const stray = Model(9)
+
+	if _, err := s.db.Exec(
+		"INSERT INTO embedding_models (id, name, dimensions) VALUES (?, 'stray', 8)",
+		int(stray),
+	); err != nil {
+		t.Fatalf("inserting model: %v", err)
+	}
+
+	err := s.loadModelMetadata(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "isn't supported by this build") {
+		t.Fatalf("expected the unknown model to be rejected, got %v", err)
+	}
+
+	if name := stray.String(); name != "Model(9)" {
+		t.Errorf("got name %q for an unknown model", name)
+	}
+
+	if _, err := stray.MarshalText(); err == nil {
+		t.Error("expected marshalling an unknown model to fail")
+	}
+}
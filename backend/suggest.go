@@ -0,0 +1,168 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WordSuggestion is a word whose name starts with the typed prefix.
+type WordSuggestion struct {
+	ID   int64  `json:"id"`
+	Word string `json:"word"`
+}
+
+// Suggestions are the cheap results for a partially typed query.
+type Suggestions struct {
+	// Words are the words whose names start with the query.
+	Words []WordSuggestion `json:"words"`
+	// Results are the matching words of the models which already have the
+	// query's embedding cached, e.g. because it has been searched for before.
+	Results map[Model][]SimilarDefinition `json:"results"`
+}
+
+// cachedEmbedder is implemented by embedders which can look up an embedding
+// without computing it.
+type cachedEmbedder interface {
+	// Cached returns the embedding of the phrase, if it is cached.
+	Cached(phrase string) (Embedding, bool)
+}
+
+var _ cachedEmbedder = &cachingEmbedder{}
+
+// Cached returns the embedding of the phrase, if it is cached.
+func (c *cachingEmbedder) Cached(phrase string) (Embedding, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[phrase]
+	if !ok {
+		return nil, false
+	}
+
+	return element.Value.(*cacheEntry).embedding, true
+}
+
+// Suggest returns suggestions for a partially typed query, without embedding
+// it: the words starting with the query, and the results of any models with
+// the query's embedding already cached. It is cheap enough to be called as the
+// user types. If `safe` is set, only words rated [ContentRatingSafe] are
+// suggested.
+func (s *Searcher) Suggest(
+	ctx context.Context,
+	query string,
+	limit int,
+	safe bool,
+) (_ *Suggestions, err error) {
+	ctx, span := tracer.Start(
+		ctx,
+		"Searcher.Suggest",
+		trace.WithAttributes(
+			attribute.Int("query_length", len(query)),
+			attribute.Int("limit", limit),
+			attribute.Bool("safe", safe),
+		),
+	)
+	defer func() { endSpan(span, err) }()
+
+	words, err := s.sqliteVec.SuggestWords(ctx, query, limit, safe)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := &Suggestions{
+		Words:   words,
+		Results: make(map[Model][]SimilarDefinition),
+	}
+
+	for model, embedder := range s.embedders {
+		cache, ok := embedder.(cachedEmbedder)
+		if !ok {
+			continue
+		}
+
+		embedding, ok := cache.Cached(query)
+		if !ok {
+			continue
+		}
+
+		results, err := s.sqliteVec.RelatedWords(ctx, model, embedding, limit, safe)
+		if err != nil {
+			return nil, fmt.Errorf("searching in SQLiteVec: %w", err)
+		}
+
+		suggestions.Results[model] = results
+	}
+
+	span.SetAttributes(attribute.Int("cached_models", len(suggestions.Results)))
+
+	return suggestions, nil
+}
+
+// likeEscaper escapes the wildcards of a `LIKE` pattern, using `\` as the
+// escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// suggestWordsQuery finds the distinct words starting with a `LIKE` prefix
+// pattern.
+//
+// SQLite's LIKE optimisation turns the prefix match into a range scan of the
+// `words_word_nocase` index, as `LIKE` is case-insensitive and the index uses
+// the matching NOCASE collation. This holds for bound patterns which don't
+// start with a wildcard, even with an `ESCAPE` clause.
+const suggestWordsQuery = `
+	SELECT MIN(id), word
+	FROM words
+	WHERE word LIKE ? ESCAPE '\'
+		AND (? = 0 OR content_rating = 'safe')
+	GROUP BY word
+	ORDER BY length(word), word
+	LIMIT ?
+`
+
+// SuggestWords returns up to `limit` distinct words starting with the prefix
+// (case-insensitively), shortest first. If `safe` is set, only words rated
+// [ContentRatingSafe] are returned.
+func (s *SQLiteVec) SuggestWords(
+	ctx context.Context,
+	prefix string,
+	limit int,
+	safe bool,
+) (_ []WordSuggestion, err error) {
+	ctx, span := startSQLiteSpan(ctx, "SuggestWords", attribute.Int("limit", limit))
+	defer func() { endSpan(span, err) }()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		suggestWordsQuery,
+		likeEscaper.Replace(prefix)+"%",
+		safe,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying words: %w", err)
+	}
+
+	defer rows.Close()
+
+	var suggestions []WordSuggestion
+
+	for rows.Next() {
+		var suggestion WordSuggestion
+
+		if err := rows.Scan(&suggestion.ID, &suggestion.Word); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return suggestions, nil
+}
@@ -0,0 +1,56 @@
+package backend
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSuggestWordsUsesIndex(t *testing.T) {
+	s := newTestSQLiteVec(t)
+
+	// Prefixes containing wildcards are escaped, which mustn't prevent the
+	// LIKE optimisation.
+	for _, prefix := range []string{"smi", "a_b", "%ab"} {
+		t.Run(prefix, func(t *testing.T) {
+			rows, err := s.db.Query(
+				"EXPLAIN QUERY PLAN "+suggestWordsQuery,
+				likeEscaper.Replace(prefix)+"%",
+				false,
+				10,
+			)
+			if err != nil {
+				t.Fatalf("explaining query: %v", err)
+			}
+
+			defer rows.Close()
+
+			var plan []string
+
+			for rows.Next() {
+				var (
+					id, parent, unused int
+					detail             string
+				)
+
+				if err := rows.Scan(&id, &parent, &unused, &detail); err != nil {
+					t.Fatalf("scanning plan: %v", err)
+				}
+
+				plan = append(plan, detail)
+			}
+
+			if err := rows.Err(); err != nil {
+				t.Fatalf("iterating plan: %v", err)
+			}
+
+			usesIndex := slices.ContainsFunc(plan, func(detail string) bool {
+				return strings.HasPrefix(detail, "SEARCH words USING INDEX words_word_nocase")
+			})
+
+			if !usesIndex {
+				t.Errorf("expected a search of the words_word_nocase index, got plan:\n%s", strings.Join(plan, "\n"))
+			}
+		})
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 const (
@@ -85,7 +87,8 @@ func NewSwamaAPI(endpoint url.URL) (*SwamaAPI, error) {
 	return &SwamaAPI{
 		endpoint: endpoint,
 		client: &http.Client{
-			Timeout: 5 * time.Minute,
+			Timeout:   5 * time.Minute,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
 		},
 	}, nil
 }
@@ -94,11 +97,13 @@ func (s *SwamaAPI) EmbedQuery(
 	ctx context.Context,
 	queries ...string,
 ) ([]SwamaEmbedding, error) {
-	for i := range queries {
-		queries[i] = fmt.Sprintf("Instruct: find the most similar definition\nQuery: %s", queries[i])
+	instructed := make([]string, len(queries))
+
+	for i, query := range queries {
+		instructed[i] = fmt.Sprintf("Instruct: find the most similar definition\nQuery: %s", query)
 	}
 
-	embeddings, err := s.Embed(ctx, queries...)
+	embeddings, err := s.Embed(ctx, instructed...)
 	if err != nil {
 		return nil, fmt.Errorf("embedding phrase: %w", err)
 	}
@@ -166,6 +171,35 @@ func (s *SwamaAPI) Embed(
 	return embeddings, nil
 }
 
+// Probe checks that the Swama API is reachable, by listing its models.
+func (s *SwamaAPI) Probe(ctx context.Context) error {
+	endpoint := s.endpoint
+	endpoint.Path = "/v1/models"
+
+	httpReq, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		endpoint.String(),
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to list models: %s", resp.Status)
+	}
+
+	return nil
+}
+
 // Complete will generate a completion for the given prompt using the swama API.
 func (s *SwamaAPI) Complete(ctx context.Context, prompt string, data string) (string, error) {
 	req := SwamaCompletionRequest{
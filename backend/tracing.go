@@ -0,0 +1,140 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans for the backend package.
+var tracer = otel.Tracer("github.com/Crystalix007/reverse-dict/backend")
+
+// TraceExporter selects where spans are sent.
+type TraceExporter string
+
+const (
+	// TraceExporterNone disables tracing.
+	TraceExporterNone TraceExporter = "none"
+	// TraceExporterOTLP sends spans to an OTLP/HTTP collector, configured
+	// with the standard `OTEL_EXPORTER_OTLP_*` environment variables.
+	TraceExporterOTLP TraceExporter = "otlp"
+	// TraceExporterStdout writes spans to stdout, for local debugging.
+	TraceExporterStdout TraceExporter = "stdout"
+)
+
+// TraceExporterFromString parses the name of a trace exporter.
+func TraceExporterFromString(s string) (TraceExporter, error) {
+	switch exporter := TraceExporter(s); exporter {
+	case TraceExporterNone, TraceExporterOTLP, TraceExporterStdout:
+		return exporter, nil
+	}
+
+	return "", fmt.Errorf("unknown trace exporter: %s", s)
+}
+
+// SetupTracing installs the global OpenTelemetry tracer provider and W3C trace
+// context propagator.
+//
+// The returned function flushes any buffered spans, and must be called before
+// the process exits.
+func SetupTracing(
+	ctx context.Context,
+	serviceName string,
+	exporter TraceExporter,
+) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(
+		propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	)
+
+	var spanExporter sdktrace.SpanExporter
+
+	switch exporter {
+	case TraceExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case TraceExporterOTLP:
+		otlpExporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+		}
+
+		spanExporter = otlpExporter
+	case TraceExporterStdout:
+		stdoutExporter, err := stdouttrace.New(
+			stdouttrace.WithWriter(os.Stdout),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("creating stdout exporter: %w", err)
+		}
+
+		spanExporter = stdoutExporter
+	default:
+		return nil, fmt.Errorf("unknown trace exporter: %s", exporter)
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+		),
+	)
+	if err != nil && !errors.Is(err, resource.ErrSchemaURLConflict) {
+		return nil, fmt.Errorf("creating trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// startSQLiteSpan starts a span around a SQLite query.
+func startSQLiteSpan(
+	ctx context.Context,
+	operation string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	return tracer.Start(
+		ctx,
+		"SQLiteVec."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			append(
+				[]attribute.KeyValue{
+					semconv.DBSystemSqlite,
+					semconv.DBOperationName(operation),
+				},
+				attrs...,
+			)...,
+		),
+	)
+}
+
+// endSpan records the error (if any) on the span, then ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
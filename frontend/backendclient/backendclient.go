@@ -1,55 +1,482 @@
 package backendclient
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
-	"path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Crystalix007/reverse-dict/backend"
+)
+
+//go:generate go -C ../../backend run ./cmd/revdict openapi --openapi-version 3.0 --output ../frontend/backendclient/openapi.yaml
+//go:generate go tool oapi-codegen -config oapi-codegen.yaml openapi.yaml
+
+var requestDuration = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "revdict",
+		Subsystem: "frontend",
+		Name:      "backend_request_duration_seconds",
+		Help:      "Duration of requests to the backend API, by operation and status (0 if the request failed).",
+		Buckets:   prometheus.DefBuckets,
+	},
+	[]string{"operation", "status"},
 )
 
-// Get performs a GET request to the backend API.
-func Get[T any](
+// tracer creates the spans for backend requests.
+var tracer = otel.Tracer("github.com/Crystalix007/reverse-dict/frontend/backendclient")
+
+// client propagates the trace context to the backend.
+var client = &http.Client{
+	Transport: otelhttp.NewTransport(http.DefaultTransport),
+}
+
+// APIError is an error response from the backend, decoded from its problem
+// details (RFC 9457) body.
+type APIError struct {
+	Status int
+	Title  string
+	Detail string
+	// RetryAfter is how long to wait before retrying, if the backend said.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("backendclient: %d %s", e.Status, e.Title)
+	}
+
+	return fmt.Sprintf("backendclient: %d %s: %s", e.Status, e.Title, e.Detail)
+}
+
+// Backend is a typed client for the backend API, wrapping the client
+// generated from its OpenAPI description.
+type Backend struct {
+	api *ClientWithResponses
+}
+
+// New creates a client for the backend API at the base URL, authenticating
+// with the given API key (if non-empty).
+func New(baseURL url.URL, apiKey string) (*Backend, error) {
+	api, err := NewClientWithResponses(
+		baseURL.String(),
+		WithHTTPClient(client),
+		WithRequestEditorFn(func(ctx context.Context, req *http.Request) error {
+			if apiKey != "" {
+				req.Header.Set("Authorization", "Bearer "+apiKey)
+			}
+
+			if user, ok := ctx.Value(endUserContextKey{}).(string); ok {
+				req.Header.Set(backend.EndUserHeader, user)
+			}
+
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("backendclient: creating client: %w", err)
+	}
+
+	return &Backend{api: api}, nil
+}
+
+type endUserContextKey struct{}
+
+// WithEndUser returns a context for requests made on behalf of the user at the
+// given address. The backend rate limits each user separately, if the API key
+// has the proxy scope.
+func WithEndUser(ctx context.Context, address string) context.Context {
+	return context.WithValue(ctx, endUserContextKey{}, address)
+}
+
+// ModelResults are the search results of one model.
+type ModelResults struct {
+	Model       ModelDescription
+	Definitions []SimilarDefinition
+}
+
+// FusedResult is a result re-ranked by the backend's active ranker.
+type FusedResult struct {
+	SimilarDefinition
+	// Model and Rank are where the word was ranked highest in the results of
+	// the individual models, which feedback on it is recorded against.
+	Model string
+	Rank  int
+}
+
+// SearchResults are the results of a search.
+type SearchResults struct {
+	// Models are the results of each model, in the backend's order of the
+	// models.
+	Models []ModelResults
+	// Fused are the results of all the models in the order of the backend's
+	// active ranker, or nil if no ranker is active.
+	Fused []FusedResult
+	// LowConfidence is whether none of the results are confident matches.
+	LowConfidence bool
+	// RewrittenQuery is the rephrased query the results are for, if the
+	// backend rephrased the query to find closer matches.
+	RewrittenQuery string
+	// Filtered is whether a safe search found nothing, but words which aren't
+	// rated safe were excluded, so may have matched.
+	Filtered bool
+}
+
+// Empty reports whether no model returned any results.
+func (r *SearchResults) Empty() bool {
+	for _, results := range r.Models {
+		if len(results.Definitions) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Search returns up to `limit` words matching the query from each model. If
+// `safe` is set, only words rated safe for all audiences are returned.
+func (b *Backend) Search(
 	ctx context.Context,
-	baseURL url.URL,
-	overlayURL url.URL,
-) (*T, error) {
-	client := &http.Client{}
+	query string,
+	limit int,
+	safe bool,
+) (*SearchResults, error) {
+	limit64 := int64(limit)
+
+	res, err := call(ctx, "search", func(ctx context.Context) (*SearchResponse, error) {
+		return b.api.SearchWithResponse(ctx, &SearchParams{
+			Query: query,
+			Limit: &limit64,
+			Safe:  &safe,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.JSON200 == nil {
+		return nil, responseError(res.HTTPResponse, res.Body, res.ApplicationproblemJSONDefault)
+	}
+
+	var models []ModelDescription
+
+	if res.JSON200.Models != nil {
+		models = *res.JSON200.Models
+	}
+
+	results := SearchResults{
+		Models:        make([]ModelResults, 0, len(models)),
+		LowConfidence: res.JSON200.Confidence == SearchResponseBodyConfidenceLow,
+		Filtered:      res.JSON200.Filtered != nil && *res.JSON200.Filtered,
+	}
+
+	if res.JSON200.RewrittenQuery != nil {
+		results.RewrittenQuery = *res.JSON200.RewrittenQuery
+	}
+
+	for _, model := range models {
+		definitions := res.JSON200.Results[model.Name]
+		if definitions == nil {
+			continue
+		}
+
+		results.Models = append(results.Models, ModelResults{
+			Model:       model,
+			Definitions: *definitions,
+		})
+	}
+
+	if res.JSON200.Fused != nil {
+		results.Fused = fuse(*res.JSON200.Fused, results.Models)
+	}
+
+	return &results, nil
+}
+
+// fuse finds where each fused result was ranked highest by the individual
+// models. Ties go to the first model in the backend's order.
+func fuse(fused []SimilarDefinition, models []ModelResults) []FusedResult {
+	results := make([]FusedResult, 0, len(fused))
+
+	for _, definition := range fused {
+		result := FusedResult{SimilarDefinition: definition}
+
+		for _, model := range models {
+			rank := slices.IndexFunc(model.Definitions, func(d SimilarDefinition) bool {
+				return d.Id == definition.Id
+			}) + 1
+
+			if rank > 0 && (result.Rank == 0 || rank < result.Rank) {
+				result.Model = model.Model.Name
+				result.Rank = rank
+			}
+		}
+
+		// Every fused result comes from some model's results, but one which
+		// can't be attributed can't be given feedback either.
+		if result.Rank == 0 {
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// Suggest returns up to `limit` suggestions of each kind for a partially
+// typed query. If `safe` is set, only words rated safe for all audiences are
+// suggested.
+func (b *Backend) Suggest(ctx context.Context, query string, limit int, safe bool) (*Suggestions, error) {
+	limit64 := int64(limit)
+
+	res, err := call(ctx, "suggest", func(ctx context.Context) (*SuggestResponse, error) {
+		return b.api.SuggestWithResponse(ctx, &SuggestParams{
+			Query: query,
+			Limit: &limit64,
+			Safe:  &safe,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.JSON200 == nil {
+		return nil, responseError(res.HTTPResponse, res.Body, res.ApplicationproblemJSONDefault)
+	}
+
+	return res.JSON200, nil
+}
+
+// GetWord returns the word with the given ID, and its features.
+func (b *Backend) GetWord(ctx context.Context, id int64) (*WordResponseBody, error) {
+	res, err := call(ctx, "get-word", func(ctx context.Context) (*GetWordResponse, error) {
+		return b.api.GetWordWithResponse(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	joinedURL := url.URL{
-		Scheme:   baseURL.Scheme,
-		Host:     baseURL.Host,
-		Path:     path.Join(baseURL.Path, overlayURL.Path),
-		RawQuery: overlayURL.RawQuery,
-		Fragment: overlayURL.Fragment,
+	if res.JSON200 == nil {
+		return nil, responseError(res.HTTPResponse, res.Body, res.ApplicationproblemJSONDefault)
 	}
 
+	return res.JSON200, nil
+}
+
+// AddFeedback records a user's verdict on a search result.
+func (b *Backend) AddFeedback(ctx context.Context, feedback AddFeedbackRequest) error {
+	res, err := call(ctx, "add-feedback", func(ctx context.Context) (*AddFeedbackResponse, error) {
+		return b.api.AddFeedbackWithResponse(ctx, feedback)
+	})
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode() != http.StatusNoContent {
+		return responseError(res.HTTPResponse, res.Body, res.ApplicationproblemJSONDefault)
+	}
+
+	return nil
+}
+
+// GetQueryAnalytics summarises the searches logged in the past days, listing
+// up to `limit` queries. Requires an admin API key.
+func (b *Backend) GetQueryAnalytics(ctx context.Context, days int, limit int) (*QueryAnalytics, error) {
+	days64, limit64 := int64(days), int64(limit)
+
+	res, err := call(ctx, "get-query-analytics", func(ctx context.Context) (*GetQueryAnalyticsResponse, error) {
+		return b.api.GetQueryAnalyticsWithResponse(ctx, &GetQueryAnalyticsParams{
+			Days:  &days64,
+			Limit: &limit64,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.JSON200 == nil {
+		return nil, responseError(res.HTTPResponse, res.Body, res.ApplicationproblemJSONDefault)
+	}
+
+	return res.JSON200, nil
+}
+
+// ListAPIKeys lists all the issued API keys. Requires an admin API key.
+func (b *Backend) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	res, err := call(ctx, "list-api-keys", func(ctx context.Context) (*ListApiKeysResponse, error) {
+		return b.api.ListApiKeysWithResponse(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.JSON200 == nil {
+		return nil, responseError(res.HTTPResponse, res.Body, res.ApplicationproblemJSONDefault)
+	}
+
+	if res.JSON200.Keys == nil {
+		return nil, nil
+	}
+
+	return *res.JSON200.Keys, nil
+}
+
+// GetAPIKeyUsage returns the daily usage of an API key. Requires an admin API
+// key.
+func (b *Backend) GetAPIKeyUsage(ctx context.Context, id int64) ([]APIKeyUsage, error) {
+	res, err := call(ctx, "get-api-key-usage", func(ctx context.Context) (*GetApiKeyUsageResponse, error) {
+		return b.api.GetApiKeyUsageWithResponse(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.JSON200 == nil {
+		return nil, responseError(res.HTTPResponse, res.Body, res.ApplicationproblemJSONDefault)
+	}
+
+	if res.JSON200.Usage == nil {
+		return nil, nil
+	}
+
+	return *res.JSON200.Usage, nil
+}
+
+// RevokeAPIKey revokes an API key. Requires an admin API key.
+func (b *Backend) RevokeAPIKey(ctx context.Context, id int64) error {
+	res, err := call(ctx, "revoke-api-key", func(ctx context.Context) (*RevokeApiKeyResponse, error) {
+		return b.api.RevokeApiKeyWithResponse(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode() != http.StatusNoContent {
+		return responseError(res.HTTPResponse, res.Body, res.ApplicationproblemJSONDefault)
+	}
+
+	return nil
+}
+
+// call performs a backend operation, tracing it and recording its duration.
+func call[R interface{ StatusCode() int }](
+	ctx context.Context,
+	operation string,
+	do func(ctx context.Context) (R, error),
+) (_ R, err error) {
+	ctx, span := tracer.Start(
+		ctx,
+		"backendclient."+operation,
+		trace.WithAttributes(attribute.String("operation", operation)),
+	)
+
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}()
+
 	var statusCode int
 
+	start := time.Now()
+
 	defer func() {
-		slog.InfoContext(ctx, "backendclient: performing GET request", slog.String("url", joinedURL.String()), slog.Int("status_code", statusCode))
+		requestDuration.WithLabelValues(
+			operation,
+			strconv.Itoa(statusCode),
+		).Observe(time.Since(start).Seconds())
+
+		slog.DebugContext(ctx, "backendclient: performed request", slog.String("operation", operation), slog.Int("status_code", statusCode))
 	}()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", joinedURL.String(), nil)
+	res, err := do(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("backendclient: creating GET request: %w", err)
+		var zero R
+
+		return zero, fmt.Errorf("backendclient: performing %s request: %w", operation, err)
 	}
 
-	res, err := client.Do(req)
+	statusCode = res.StatusCode()
+	span.SetAttributes(attribute.Int("status_code", statusCode))
+
+	return res, nil
+}
+
+// responseError returns the error for an unsuccessful response, preferring the
+// problem details decoded from its body.
+func responseError(res *http.Response, body []byte, problem *ErrorModel) error {
+	apiErr := &APIError{
+		Status: res.StatusCode,
+		Title:  http.StatusText(res.StatusCode),
+	}
+
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	if problem == nil {
+		// Not a problem details response, e.g. from a proxy in front of the
+		// backend, so the body is the best explanation available.
+		apiErr.Detail = string(bytes.TrimSpace(body[:min(len(body), 4096)]))
+
+		return apiErr
+	}
+
+	if problem.Status != nil {
+		apiErr.Status = int(*problem.Status)
+	}
+
+	if problem.Title != nil {
+		apiErr.Title = *problem.Title
+	}
+
+	if problem.Detail != nil {
+		apiErr.Detail = *problem.Detail
+	}
+
+	return apiErr
+}
+
+// CheckReady queries the readiness endpoint of the backend, which is served
+// from the root of the backend server rather than under the API prefix.
+func CheckReady(ctx context.Context, baseURL url.URL) error {
+	readyURL := baseURL.ResolveReference(&url.URL{Path: "/readyz"})
+
+	req, err := http.NewRequestWithContext(ctx, "GET", readyURL.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("backendclient: performing GET request: %w", err)
+		return fmt.Errorf("backendclient: creating readiness request: %w", err)
 	}
 
-	statusCode = res.StatusCode
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("backendclient: performing readiness request: %w", err)
+	}
 
 	defer res.Body.Close()
 
-	var result T
-	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("backendclient: decoding GET response: %w", err)
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+
+		return fmt.Errorf("backendclient: backend not ready: %s: %s", res.Status, bytes.TrimSpace(body))
 	}
 
-	return &result, nil
+	return nil
 }
@@ -4,18 +4,25 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
+	"github.com/Crystalix007/reverse-dict/backend"
 	"github.com/Crystalix007/reverse-dict/frontend"
 )
 
 type args struct {
-	listenAddress string
-	serverAddress string
+	listenAddress   string
+	serverAddress   string
+	apiKey          string
+	traceExporter   string
+	shutdownTimeout time.Duration
 }
 
 func main() {
@@ -31,9 +38,17 @@ func main() {
 
 	cmd.Flags().StringVarP(&args.listenAddress, "listen", "l", "localhost:3000", "Address to bind the server to")
 	cmd.Flags().StringVarP(&args.serverAddress, "server", "s", "http://localhost:8080/api/", "Address of the backend server")
+	cmd.Flags().StringVar(&args.traceExporter, "trace-exporter", string(backend.TraceExporterNone), "Where to send traces (none, otlp, stdout); otlp is configured with the OTEL_EXPORTER_OTLP_* environment variables")
+	cmd.Flags().StringVar(&args.apiKey, "api-key", os.Getenv("REVDICT_API_KEY"), "API key for the backend server, with the search and proxy scopes (defaults to $REVDICT_API_KEY)")
+	cmd.Flags().DurationVar(&args.shutdownTimeout, "shutdown-timeout", backend.DefaultShutdownTimeout, "How long to wait for in-flight requests to complete when shutting down")
 
-	if err := cmd.Execute(); err != nil {
+	// Cancel the context on SIGINT/SIGTERM, so that the server can drain.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.ExecuteContext(ctx); err != nil {
 		slog.Error("error starting server", slog.Any("error", err))
+		stop()
 		os.Exit(1)
 	}
 }
@@ -46,5 +61,24 @@ func run(ctx context.Context, args args) error {
 		return fmt.Errorf("parsing server address: %w", err)
 	}
 
-	return http.ListenAndServe(args.listenAddress, frontend.Serve(*serverURL))
+	traceExporter, err := backend.TraceExporterFromString(args.traceExporter)
+	if err != nil {
+		return fmt.Errorf("parsing trace exporter: %w", err)
+	}
+
+	shutdownTracing, err := backend.SetupTracing(ctx, "revdict-frontend", traceExporter)
+	if err != nil {
+		return fmt.Errorf("setting up tracing: %w", err)
+	}
+
+	defer shutdownTracing(context.Background())
+
+	handler, err := frontend.Serve(*serverURL, args.apiKey)
+	if err != nil {
+		return err
+	}
+
+	server := backend.NewHTTPServer(args.listenAddress, otelhttp.NewHandler(handler, "frontend"))
+
+	return backend.ListenAndServe(ctx, server, args.shutdownTimeout)
 }
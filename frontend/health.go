@@ -0,0 +1,62 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Crystalix007/reverse-dict/frontend/backendclient"
+)
+
+// readinessTimeout bounds how long the backend readiness check may take.
+const readinessTimeout = 5 * time.Second
+
+type healthResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// healthz reports that the process is alive.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, r, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// readyz reports whether the frontend can serve searches, which requires the
+// backend to be ready.
+func readyz(backendURL url.URL) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		if err := backendclient.CheckReady(ctx, backendURL); err != nil {
+			slog.WarnContext(ctx, "backend is not ready", slog.Any("error", err))
+
+			writeHealth(w, r, http.StatusServiceUnavailable, healthResponse{
+				Status: "error",
+				Error:  err.Error(),
+			})
+
+			return
+		}
+
+		writeHealth(w, r, http.StatusOK, healthResponse{Status: "ok"})
+	}
+}
+
+func writeHealth(
+	w http.ResponseWriter,
+	r *http.Request,
+	status int,
+	response healthResponse,
+) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "writing health response", slog.Any("error", err))
+	}
+}
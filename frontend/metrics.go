@@ -0,0 +1,57 @@
+package frontend
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var httpRequestDuration = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "revdict",
+		Subsystem: "frontend",
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests, by route.",
+		Buckets:   prometheus.DefBuckets,
+	},
+	[]string{"method", "route", "status"},
+)
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap allows [http.ResponseController] to reach the underlying writer.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// instrument records the duration of requests served by the handler,
+// labelled with the route pattern it was registered under.
+func instrument(route string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+
+		handler.ServeHTTP(recorder, r)
+
+		httpRequestDuration.WithLabelValues(
+			r.Method,
+			route,
+			strconv.Itoa(recorder.status),
+		).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Crystalix007/reverse-dict/frontend/backendclient"
+)
+
+// verdicts are the verdicts the backend accepts.
+var verdicts = []backendclient.AddFeedbackRequestVerdict{
+	backendclient.Relevant,
+	backendclient.Irrelevant,
+	backendclient.Meant,
+}
+
+// Feedback records the user's verdict on a search result, replacing the
+// feedback buttons with an acknowledgement.
+func (h *Handler) Feedback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	wordID, err := strconv.ParseInt(r.Form.Get("word_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid word ID", http.StatusBadRequest)
+		return
+	}
+
+	rank, err := strconv.ParseInt(r.Form.Get("rank"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid rank", http.StatusBadRequest)
+		return
+	}
+
+	verdict := backendclient.AddFeedbackRequestVerdict(r.Form.Get("verdict"))
+	if !slices.Contains(verdicts, verdict) {
+		http.Error(w, "Invalid verdict", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.backend.AddFeedback(ctx, backendclient.AddFeedbackRequest{
+		Query:   r.Form.Get("query"),
+		Model:   r.Form.Get("model"),
+		WordId:  wordID,
+		Rank:    rank,
+		Verdict: verdict,
+	}); err != nil {
+		slog.ErrorContext(ctx, "sending feedback failed", slog.Int64("word_id", wordID), slog.Any("error", err))
+		render(w, r, http.StatusBadGateway, feedbackFailed())
+
+		return
+	}
+
+	render(w, r, http.StatusOK, feedbackSent())
+}
@@ -1,51 +1,146 @@
 package routes
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
-	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ"
 
-	"github.com/Crystalix007/reverse-dict/backend"
 	"github.com/Crystalix007/reverse-dict/frontend/backendclient"
 )
 
-// SearchResults renders the search results page.
-func (h *Handler) SearchResults(w http.ResponseWriter, r *http.Request) {
-	words := make(map[backend.Model][]backend.SimilarDefinition)
+// searchLimit is the number of results shown for each model.
+const searchLimit = 10
+
+// Index renders the search page, without any results.
+func (h *Handler) Index(w http.ResponseWriter, r *http.Request) {
+	render(w, r, http.StatusOK, page("", false, nil))
+}
 
+// SearchResults renders the results of a search. htmx requests get just the
+// results fragment, and other requests (e.g. following a shared link) get the
+// full page.
+func (h *Handler) SearchResults(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
 		http.Error(w, "Failed to parse form", http.StatusBadRequest)
 		return
 	}
 
-	searchInput := r.Form.Get("query")
-
-	if searchInput != "" {
-		searchResults, err := backendclient.Get[backend.SearchResponseBody](
-			r.Context(),
-			h.backendURL,
-			url.URL{
-				Path: "search",
-				RawQuery: url.Values{
-					"query": []string{searchInput},
-				}.Encode(),
-			},
-		)
-		if err != nil {
-			slog.ErrorContext(r.Context(), "Failed to get search results", slog.String("query", searchInput), slog.Any("error", err))
-			http.Error(w, "Failed to get search results", http.StatusInternalServerError)
-			return
+	searchInput := strings.TrimSpace(r.Form.Get("query"))
+	explicit := showExplicit(r)
+
+	// The response differs between htmx and other requests, so mustn't be
+	// cached for the wrong one.
+	w.Header().Add("Vary", "HX-Request")
+
+	fullPage := r.Header.Get("HX-Request") != "true" ||
+		r.Header.Get("HX-History-Restore-Request") == "true"
+
+	if searchInput == "" && fullPage {
+		render(w, r, http.StatusOK, page("", explicit, nil))
+		return
+	}
+
+	status, component := h.search(w, r, searchInput, explicit)
+
+	if fullPage {
+		component = page(searchInput, explicit, component)
+	}
+
+	render(w, r, status, component)
+}
+
+// search returns the status code and fragment for the results of the search.
+func (h *Handler) search(w http.ResponseWriter, r *http.Request, query string, explicit bool) (int, templ.Component) {
+	if query == "" {
+		return http.StatusUnprocessableEntity, invalidQuery("Describe the word you are looking for.")
+	}
+
+	results, err := h.backend.Search(r.Context(), query, searchLimit, !explicit)
+	if err != nil {
+		return searchFailed(w, r, query, err)
+	}
+
+	if results.Empty() {
+		if results.Filtered {
+			return http.StatusNotFound, filteredResults(query)
 		}
 
-		for model, results := range searchResults.Results {
-			words[model] = make([]backend.SimilarDefinition, 0, len(results))
+		return http.StatusNotFound, noResults(query)
+	}
 
-			for _, result := range results {
-				words[model] = append(words[model], result)
-			}
+	return http.StatusOK, searchResults(query, results)
+}
+
+// searchFailed returns the status code and fragment explaining why the search
+// failed.
+func searchFailed(w http.ResponseWriter, r *http.Request, query string, err error) (int, templ.Component) {
+	ctx := r.Context()
+
+	var apiErr *backendclient.APIError
+
+	if !errors.As(err, &apiErr) {
+		if errors.Is(err, context.Canceled) {
+			// The client has gone away, so won't see this anyway.
+			slog.DebugContext(ctx, "search canceled", slog.String("query", query))
+		} else {
+			slog.ErrorContext(ctx, "backend unreachable", slog.String("query", query), slog.Any("error", err))
+		}
+
+		return http.StatusServiceUnavailable, backendDown()
+	}
+
+	attrs := []any{
+		slog.String("query", query),
+		slog.Int("status", apiErr.Status),
+		slog.String("title", apiErr.Title),
+		slog.String("detail", apiErr.Detail),
+	}
+
+	switch apiErr.Status {
+	case http.StatusNotFound:
+		slog.InfoContext(ctx, "no search results", attrs...)
+
+		return http.StatusNotFound, noResults(query)
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		slog.InfoContext(ctx, "backend rejected search", attrs...)
+
+		return http.StatusUnprocessableEntity, invalidQuery(apiErr.Detail)
+	case http.StatusTooManyRequests:
+		slog.WarnContext(ctx, "backend rate limited search", attrs...)
+
+		if apiErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(apiErr.RetryAfter.Seconds()))))
 		}
+
+		return http.StatusTooManyRequests, rateLimited(apiErr.RetryAfter)
+	default:
+		slog.ErrorContext(ctx, "backend search failed", attrs...)
+
+		return http.StatusBadGateway, backendDown()
 	}
+}
+
+// showExplicit reports whether the user has opted in to explicit and
+// offensive results. They are filtered out by default, including when
+// following links which predate the option.
+func showExplicit(r *http.Request) bool {
+	return r.Form.Get("explicit") == "on"
+}
 
-	component := searchResults(words)
-	component.Render(r.Context(), w)
+// render writes the component with the status code. htmx is configured (in
+// [page]) to swap error responses too, so that the fragments explaining them
+// are shown.
+func render(w http.ResponseWriter, r *http.Request, status int, component templ.Component) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := component.Render(r.Context(), w); err != nil {
+		slog.ErrorContext(r.Context(), "rendering component", slog.Any("error", err))
+	}
 }
@@ -0,0 +1,88 @@
+package routes
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Crystalix007/reverse-dict/frontend/backendclient"
+)
+
+const (
+	// minSuggestLength is the shortest query to make suggestions for, as
+	// shorter prefixes match too many words to be useful.
+	minSuggestLength = 2
+	// suggestLimit is the number of suggestions of each kind shown.
+	suggestLimit = 5
+)
+
+// Suggestions renders the suggestions for a partially typed query. It is
+// requested by htmx as the user types, which aborts superseded requests, so
+// their backend requests are canceled along with the request context.
+func (h *Handler) Suggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	query := strings.TrimSpace(r.Form.Get("query"))
+
+	if len(query) < minSuggestLength {
+		render(w, r, http.StatusOK, suggestions(nil, nil))
+		return
+	}
+
+	results, err := h.backend.Suggest(ctx, query, suggestLimit, !showExplicit(r))
+	if errors.Is(err, context.Canceled) {
+		slog.DebugContext(ctx, "suggestions canceled", slog.String("query", query))
+		return
+	} else if err != nil {
+		// Suggestions are best-effort, so failures only clear them.
+		slog.WarnContext(ctx, "getting suggestions failed", slog.String("query", query), slog.Any("error", err))
+		render(w, r, http.StatusOK, suggestions(nil, nil))
+
+		return
+	}
+
+	var words []backendclient.WordSuggestion
+
+	if results.Words != nil {
+		words = *results.Words
+	}
+
+	render(w, r, http.StatusOK, suggestions(words, bestMatches(results.Results, suggestLimit)))
+}
+
+// bestMatches merges the results of each model, returning the `limit` closest
+// distinct words.
+func bestMatches(results map[string]*[]backendclient.SimilarDefinition, limit int) []backendclient.SimilarDefinition {
+	best := make(map[int64]backendclient.SimilarDefinition)
+
+	for _, definitions := range results {
+		if definitions == nil {
+			continue
+		}
+
+		for _, definition := range *definitions {
+			if current, ok := best[definition.Id]; !ok || definition.Distance < current.Distance {
+				best[definition.Id] = definition
+			}
+		}
+	}
+
+	matches := slices.SortedFunc(
+		maps.Values(best),
+		func(a, b backendclient.SimilarDefinition) int {
+			return cmp.Or(cmp.Compare(a.Distance, b.Distance), cmp.Compare(a.Id, b.Id))
+		},
+	)
+
+	return matches[:min(limit, len(matches))]
+}
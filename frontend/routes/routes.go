@@ -1,16 +1,16 @@
 // Package routes defines the per-route templates and construction logic.
 package routes
 
-import "net/url"
+import "github.com/Crystalix007/reverse-dict/frontend/backendclient"
 
 //go:generate go tool templ generate
 
 type Handler struct {
-	backendURL url.URL
+	backend *backendclient.Backend
 }
 
-func New(backendURL url.URL) *Handler {
+func New(backend *backendclient.Backend) *Handler {
 	return &Handler{
-		backendURL: backendURL,
+		backend: backend,
 	}
 }
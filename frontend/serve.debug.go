@@ -5,7 +5,6 @@ package frontend
 import (
 	"net/http"
 	"os"
-	"path"
 )
 
 func serveStatic() http.Handler {
@@ -18,9 +17,3 @@ func serveStatic() http.Handler {
 
 	return http.StripPrefix("/static/", http.FileServerFS(staticFS))
 }
-
-func serveFile(filename string) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, path.Join("static", filename))
-	})
-}
@@ -1,23 +1,58 @@
 package frontend
 
 import (
+	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/Crystalix007/reverse-dict/frontend/backendclient"
 	"github.com/Crystalix007/reverse-dict/frontend/routes"
 )
 
-func Serve(backendURL url.URL) http.Handler {
+func Serve(backendURL url.URL, apiKey string) (http.Handler, error) {
 	mux := http.NewServeMux()
 
-	frontendHandler := routes.New(backendURL)
+	backend, err := backendclient.New(backendURL, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("creating backend client: %w", err)
+	}
+
+	frontendHandler := routes.New(backend)
 
-	mux.Handle("GET /{$}", serveFile("index.html"))
-	mux.Handle("GET /static/", serveStatic())
-	mux.Handle("POST /search", http.HandlerFunc(frontendHandler.SearchResults))
-	mux.Handle("GET /", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handle := func(pattern string, handler http.Handler) {
+		mux.Handle(pattern, instrument(pattern, handler))
+	}
+
+	handle("GET /{$}", http.HandlerFunc(frontendHandler.Index))
+	handle("GET /static/", serveStatic())
+	handle("GET /search", http.HandlerFunc(frontendHandler.SearchResults))
+	handle("POST /search", http.HandlerFunc(frontendHandler.SearchResults))
+	handle("GET /suggest", http.HandlerFunc(frontendHandler.Suggestions))
+	handle("POST /feedback", http.HandlerFunc(frontendHandler.Feedback))
+	handle("GET /", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 	}))
 
-	return mux
+	mux.Handle("GET /metrics", promhttp.Handler())
+	mux.HandleFunc("GET /healthz", healthz)
+	mux.Handle("GET /readyz", readyz(backendURL))
+
+	return withEndUser(mux), nil
+}
+
+// withEndUser passes the address of the user on to the backend, so that the
+// frontend's users are rate limited separately rather than sharing its API
+// key's allowance.
+func withEndUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		next.ServeHTTP(w, r.WithContext(backendclient.WithEndUser(r.Context(), host)))
+	})
 }